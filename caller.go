@@ -0,0 +1,95 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+const (
+	// Max number of frames inspected looking for the caller.
+	maxCallerDepth = 32
+
+	// Max number of frames in a stack trace.
+	maxStackTraceDepth = 64
+)
+
+// Functions which are part of the printing layers, and should be skipped
+// when looking for the caller. Printers are layered (e.g.: `Infof` ->
+// `Printf` -> `PrintfWithOptions` -> `PrintMessage`), so the depth of the
+// caller varies per printer.
+var printingLayers = func() []string {
+	pkgPath := reflect.TypeOf(Sypl{}).PkgPath()
+
+	return []string{
+		pkgPath + ".(*Sypl).",
+		pkgPath + ".(*SlogHandler).",
+		pkgPath + ".(*loggerWriter).",
+		// Standard library's logger, see `RedirectStdLog`.
+		"log.",
+		"log/slog.",
+	}
+}()
+
+// Returns true if `function` is part of the printing layers.
+func isPrintingLayer(function string) bool {
+	for _, layer := range printingLayers {
+		if strings.HasPrefix(function, layer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callers returns the frames of the current goroutine's stack, starting from
+// the first function outside of the printing layers - the caller of the public
+// printer entry point. At most `depth` frames are returned. `skip` is the
+// number of additional frames to skip, on top of `callers` itself.
+//
+// Note: It must be called before any goroutine hop, otherwise the stack
+// doesn't include the caller anymore.
+func callers(skip, depth int) []runtime.Frame {
+	pcs := make([]uintptr, maxCallerDepth+depth)
+
+	// Skips `runtime.Callers`, and `callers`.
+	n := runtime.Callers(skip+2, pcs)
+
+	iter := runtime.CallersFrames(pcs[:n])
+
+	frames := []runtime.Frame{}
+
+	for {
+		frame, more := iter.Next()
+
+		if len(frames) > 0 || !isPrintingLayer(frame.Function) {
+			frames = append(frames, frame)
+		}
+
+		if !more || len(frames) == depth {
+			break
+		}
+	}
+
+	return frames
+}
+
+// caller returns the caller of the public printer entry point. If not found,
+// it's a zero-valued frame.
+func caller() runtime.Frame {
+	if frames := callers(1, 1); len(frames) > 0 {
+		return frames[0]
+	}
+
+	return runtime.Frame{}
+}
+
+// stackTrace returns the stack trace, starting from the caller of the public
+// printer entry point.
+func stackTrace() []runtime.Frame {
+	return callers(1, maxStackTraceDepth)
+}
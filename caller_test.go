@@ -0,0 +1,188 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/output"
+)
+
+func TestCaller(t *testing.T) {
+	tests := []struct {
+		name  string
+		print func(l *Sypl)
+	}{
+		{
+			name: "Should work - Info",
+			print: func(l *Sypl) {
+				l.Info("info message")
+			},
+		},
+		{
+			name: "Should work - Errorlnf",
+			print: func(l *Sypl) {
+				l.Errorlnf("%s", "error message")
+			},
+		},
+		{
+			name: "Should work - PrintMessage",
+			print: func(l *Sypl) {
+				l.PrintMessage(message.New(level.Info, "info message"))
+			},
+		},
+		{
+			name: "Should work - PrintMessagesToOutputs",
+			print: func(l *Sypl) {
+				l.PrintMessagesToOutputs(MessageToOutput{Content: "info message", Level: level.Info, OutputName: "Buffer"})
+			},
+		},
+		{
+			name: "Should work - InfoContext",
+			print: func(l *Sypl) {
+				l.InfoContext(context.Background(), "info message")
+			},
+		},
+		{
+			name: "Should work - io.Writer",
+			print: func(l *Sypl) {
+				l.SetDefaultIoWriterLevel(level.Info)
+
+				_, _ = l.Write([]byte("info message"))
+			},
+		},
+		{
+			name: "Should work - slog",
+			print: func(l *Sypl) {
+				slog.New(NewSlogHandler(l, nil)).Info("info message")
+			},
+		},
+		{
+			name: "Should work - RedirectStdLog",
+			print: func(l *Sypl) {
+				restore := RedirectStdLog(l)
+				defer restore()
+
+				log.Println("info message")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+
+			captured := []message.IMessage{}
+
+			_, o := output.SafeBuffer(level.Trace, captureProcessor(&mu, &captured))
+
+			tt.print(New("caller", o))
+
+			if len(captured) != 1 {
+				t.Fatalf("Got %d messages, want 1", len(captured))
+			}
+
+			caller := captured[0].GetCaller()
+
+			if filepath.Base(caller.File) != "caller_test.go" {
+				t.Errorf("Got caller file %s, want caller_test.go", caller.File)
+			}
+
+			if !strings.Contains(caller.Function, "TestCaller") {
+				t.Errorf("Got caller function %s, want TestCaller", caller.Function)
+			}
+		})
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	tests := []struct {
+		name            string
+		stackTraceLevel level.Level
+		print           func(l ISypl)
+		want            bool
+	}{
+		{
+			name:            "Should work - disabled",
+			stackTraceLevel: level.None,
+			print: func(l ISypl) {
+				l.Errorf("%s", "error message")
+			},
+			want: false,
+		},
+		{
+			name:            "Should work - at level",
+			stackTraceLevel: level.Error,
+			print: func(l ISypl) {
+				l.Errorf("%s", "error message")
+			},
+			want: true,
+		},
+		{
+			name:            "Should work - below level",
+			stackTraceLevel: level.Error,
+			print: func(l ISypl) {
+				l.Info("info message")
+			},
+			want: false,
+		},
+		{
+			name:            "Should work - above level",
+			stackTraceLevel: level.Warn,
+			print: func(l ISypl) {
+				l.Error("error message")
+			},
+			want: true,
+		},
+		{
+			name:            "Should work - child",
+			stackTraceLevel: level.Error,
+			print: func(l ISypl) {
+				l.New("child").Error("error message")
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+
+			captured := []message.IMessage{}
+
+			_, o := output.SafeBuffer(level.Trace, captureProcessor(&mu, &captured))
+
+			tt.print(New("stacktrace", o).SetStackTraceLevel(tt.stackTraceLevel))
+
+			if len(captured) != 1 {
+				t.Fatalf("Got %d messages, want 1", len(captured))
+			}
+
+			st := captured[0].GetStackTrace()
+
+			if (len(st) != 0) != tt.want {
+				t.Fatalf("Got stack trace %v, want %v", st, tt.want)
+			}
+
+			if !tt.want {
+				return
+			}
+
+			if !strings.Contains(st[0].Function, "TestStackTrace") {
+				t.Errorf("Got top frame %s, want TestStackTrace", st[0].Function)
+			}
+
+			if len(st) < 2 {
+				t.Errorf("Got %d frames, want more than 1", len(st))
+			}
+		})
+	}
+}
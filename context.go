@@ -0,0 +1,68 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"context"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/options"
+)
+
+// ContextExtractor extracts structured fields from a context, e.g.: request,
+// tenant, or trace IDs. Extractors are registered in the logger, and run by
+// the context-aware printers before options are merged into the message.
+type ContextExtractor func(ctx context.Context) fields.Fields
+
+//////
+// Built-in context extractors.
+//////
+
+// ContextValueExtractor returns an extractor which extracts the value stored
+// in the context under `key` as a field named `name`. Nothing is extracted if
+// there's no value.
+func ContextValueExtractor(key interface{}, name string) ContextExtractor {
+	return func(ctx context.Context) fields.Fields {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+
+		return fields.Fields{name: v}
+	}
+}
+
+//////
+// Helpers.
+//////
+
+// Runs registered extractors against the context. Later registered extractors
+// have precedence.
+func (sypl *Sypl) extractFields(ctx context.Context) fields.Fields {
+	extracted := fields.Fields{}
+
+	if ctx == nil {
+		return extracted
+	}
+
+	for _, extractor := range sypl.contextExtractors {
+		fields.Copy(extractor(ctx), extracted)
+	}
+
+	return extracted
+}
+
+// Returns a copy of `o` whose fields are the ones extracted from the context,
+// merged with `o` fields. Options' fields have precedence.
+func (sypl *Sypl) optionsWithContext(ctx context.Context, o *options.Options) *options.Options {
+	oC := *o
+
+	extracted := sypl.extractFields(ctx)
+	fields.Copy(o.Fields, extracted)
+
+	oC.Fields = extracted
+
+	return &oC
+}
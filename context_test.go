@@ -0,0 +1,101 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/options"
+	"github.com/saucelabs/sypl/output"
+)
+
+type testContextKey string
+
+const (
+	requestIDKey testContextKey = "requestID"
+	tenantIDKey  testContextKey = "tenantID"
+)
+
+func TestContextPrinters(t *testing.T) {
+	ctx := context.WithValue(
+		context.WithValue(context.Background(), requestIDKey, "r1"),
+		tenantIDKey, "t1",
+	)
+
+	tests := []struct {
+		name  string
+		print func(l *Sypl)
+		want  fields.Fields
+	}{
+		{
+			name: "Should work - InfoContext",
+			print: func(l *Sypl) {
+				l.InfoContext(ctx, "info message")
+			},
+			want: fields.Fields{"request_id": "r1", "tenant_id": "t1"},
+		},
+		{
+			name: "Should work - child logger inherits extractors",
+			print: func(l *Sypl) {
+				l.New("child").ErrorfContext(ctx, "%s", "error message")
+			},
+			want: fields.Fields{"request_id": "r1", "tenant_id": "t1"},
+		},
+		{
+			name: "Should work - options have precedence",
+			print: func(l *Sypl) {
+				l.PrintWithOptionsAndContext(ctx, &options.Options{
+					Fields: fields.Fields{"tenant_id": "t2", "a": 1},
+				}, level.Warn, "warn message")
+			},
+			want: fields.Fields{"request_id": "r1", "tenant_id": "t2", "a": 1},
+		},
+		{
+			name: "Should work - missing values",
+			print: func(l *Sypl) {
+				l.PrintlnWithContext(context.Background(), level.Info, "info message")
+			},
+			want: fields.Fields{},
+		},
+		{
+			name: "Should work - slog handler",
+			print: func(l *Sypl) {
+				slog.New(NewSlogHandler(l, nil)).InfoContext(ctx, "info message", "request_id", "r2")
+			},
+			want: fields.Fields{"request_id": "r2", "tenant_id": "t1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+
+			captured := []message.IMessage{}
+
+			_, o := output.SafeBuffer(level.Trace, captureProcessor(&mu, &captured))
+
+			l := New("context", o).AddContextExtractors(
+				ContextValueExtractor(requestIDKey, "request_id"),
+				ContextValueExtractor(tenantIDKey, "tenant_id"),
+			)
+
+			tt.print(l.(*Sypl))
+
+			if len(captured) != 1 {
+				t.Fatalf("Got %d messages, want 1", len(captured))
+			}
+
+			if diff := deep.Equal(captured[0].GetFields(), tt.want); diff != nil {
+				t.Errorf("Fields diff: %v", diff)
+			}
+		})
+	}
+}
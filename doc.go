@@ -9,6 +9,13 @@
 // The order of execution is according to the registering order. The above
 // features allow sypl to fit into many different logging flows and needs.
 //
+// Messages printed in a single call, e.g.: `PrintMessage(m1, m2, m3)`, are
+// processed concurrently, thus may be written in any order. If order matters,
+// e.g.: step-by-step CLI output, set the logger to the ordering mode via
+// `SetOrdered`, or use `PrintMessageOrdered`: each output writes messages in
+// the specified order (FIFO), while outputs still write concurrently. Messages
+// printed in separated calls are always written in the calls order.
+//
 // In a application with many loggers, and child loggers, sometimes more fine
 // control is needed, specially when debugging applications. Sypl offers two
 // powerful ways to achieve that: `SYPL_FILTER`, and `SYPL_DEBUG` env vars.
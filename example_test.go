@@ -671,3 +671,61 @@ func ExampleNew_ioWriter() {
 	// output:
 	// true
 }
+
+// Per output fields filtering example. The local output keeps all fields,
+// while the remote one gets a reduced set.
+func ExampleNew_filterFields() {
+	localBuf, local := output.SafeBuffer(level.Info)
+	remoteBuf, remote := output.SafeBuffer(level.Info, processor.FilterFields(&processor.FieldFilterOptions{
+		Allow: []string{"user"},
+		Deny:  []string{"user.*.password"},
+	}))
+
+	local.SetName("Local")
+	remote.SetName("Remote")
+
+	// Creates logger, and name it.
+	l := sypl.New(shared.DefaultComponentNameOutput,
+		local.SetFormatter(formatter.Logfmt()),
+		remote.SetFormatter(formatter.Logfmt()),
+	)
+
+	l.PrintlnWithOptions(&options.Options{
+		Fields: fields.Fields{
+			"host": "db1",
+			"user": fields.Fields{"credentials": fields.Fields{"name": "john", "password": "secret"}},
+		},
+	}, level.Info, shared.DefaultContentOutput)
+
+	fmt.Println(
+		stringContains(localBuf.String(), "host=db1", "user.credentials.password=secret"),
+		stringContains(remoteBuf.String(), "user.credentials.name=john"),
+		strings.Contains(remoteBuf.String(), "host"),
+		strings.Contains(remoteBuf.String(), "password"),
+	)
+
+	// output:
+	// true true false false
+}
+
+// Duplicate suppression example. Summaries are written to the output itself.
+func ExampleNew_dedup() {
+	buf, o := output.SafeBuffer(level.Info)
+
+	o.AddProcessors(processor.Dedup(&processor.DedupOptions{Writer: o}))
+
+	// Creates logger, and name it.
+	l := sypl.New(shared.DefaultComponentNameOutput, o)
+
+	l.Infoln("a")
+	l.Infoln("a")
+	l.Infoln("a")
+	l.Infoln("b")
+
+	fmt.Print(buf.String())
+
+	// output:
+	// a
+	// Last message repeated 2 times
+	// b
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"log"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/options"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// PanicTag tags messages printed by the `Panic` printers. They are printed @
+// the Fatal level, so outputs only writing Fatal messages don't miss them, and
+// tagged, so they don't exit, and are told apart from `Fatal` messages.
+const PanicTag = "panic"
+
+// ExitFunc is called, with the exit code, after printing `Fatal` messages.
+// Default is `os.Exit`.
+type ExitFunc func(code int)
+
+// ExitHook is called before exiting, e.g.: to release resources. Outputs are
+// flushed after all hooks run, so hooks can still print.
+type ExitHook func()
+
+//////
+// Helpers.
+//////
+
+// Runs exit hooks, in the order they were added, flushes outputs, then exits.
+func (sypl *Sypl) exit(code int) {
+	for _, hook := range sypl.exitHooks {
+		hook()
+	}
+
+	if err := sypl.Flush(); err != nil {
+		log.Println(shared.ErrorPrefix, err)
+	}
+
+	sypl.exitFunc(code)
+}
+
+// Returns true if the message is `Fatal`, and not printed by the `Panic`
+// printers.
+func shouldExitOn(m message.IMessage) bool {
+	return m.GetLevel() == level.Fatal && !m.ContainTag(PanicTag)
+}
+
+// Returns options of messages printed by the `Panic` printers.
+func panicOptions() *options.Options {
+	o := options.New()
+	o.Tags = []string{PanicTag}
+
+	return o
+}
+
+// Flushes outputs, then panics with `s`.
+func (sypl *Sypl) panic(s string) {
+	if err := sypl.Flush(); err != nil {
+		log.Println(shared.ErrorPrefix, err)
+	}
+
+	panic(s)
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/output"
+	"github.com/saucelabs/sypl/shared"
+)
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		name     string
+		print    func(l *Sypl)
+		wantCode int
+		wantLog  string
+	}{
+		{
+			name: "Should work - Fatal",
+			print: func(l *Sypl) {
+				l.Fatal(shared.DefaultContentOutput)
+			},
+			wantCode: 1,
+			wantLog:  shared.DefaultContentOutput + "hook",
+		},
+		{
+			name: "Should work - child",
+			print: func(l *Sypl) {
+				l.New("child").Fatalf("%s", shared.DefaultContentOutput)
+			},
+			wantCode: 1,
+			wantLog:  shared.DefaultContentOutput + "hook",
+		},
+		{
+			name: "Should not exit - Error",
+			print: func(l *Sypl) {
+				l.Error(shared.DefaultContentOutput)
+			},
+			wantCode: -1,
+			wantLog:  shared.DefaultContentOutput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, o := output.SafeBuffer(level.Trace)
+
+			code := -1
+			calls := []string{}
+
+			l := New("exit", o)
+			l.SetExitFunc(func(c int) {
+				calls = append(calls, "exit")
+				code = c
+			})
+			l.AddExitHooks(
+				func() { calls = append(calls, "hook1") },
+				func() {
+					calls = append(calls, "hook2")
+
+					l.Info("hook")
+				},
+			)
+
+			tt.print(l)
+
+			if code != tt.wantCode {
+				t.Errorf("Got code %d, want %d", code, tt.wantCode)
+			}
+
+			if tt.wantCode != -1 {
+				if want := []string{"hook1", "hook2", "exit"}; !reflect.DeepEqual(calls, want) {
+					t.Errorf("Got calls %v, want %v", calls, want)
+				}
+			}
+
+			if buf.String() != tt.wantLog {
+				t.Errorf("Got %s, want %s", buf.String(), tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestPanic(t *testing.T) {
+	tests := []struct {
+		name      string
+		print     func(l *Sypl)
+		wantPanic string
+	}{
+		{
+			name: "Should work - Panic",
+			print: func(l *Sypl) {
+				l.Panic(shared.DefaultContentOutput)
+			},
+			wantPanic: shared.DefaultContentOutput,
+		},
+		{
+			name: "Should work - Panicf",
+			print: func(l *Sypl) {
+				l.Panicf("%s", shared.DefaultContentOutput)
+			},
+			wantPanic: shared.DefaultContentOutput,
+		},
+		{
+			name: "Should work - Paniclnf",
+			print: func(l *Sypl) {
+				l.Paniclnf("%s", shared.DefaultContentOutput)
+			},
+			wantPanic: shared.DefaultContentOutput + "\n",
+		},
+		{
+			name: "Should work - Panicln",
+			print: func(l *Sypl) {
+				l.Panicln(shared.DefaultContentOutput)
+			},
+			wantPanic: shared.DefaultContentOutput + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Should be written by outputs only writing Fatal messages.
+			buf, o := output.SafeBuffer(level.Fatal)
+
+			l := New("panic", o)
+
+			l.SetExitFunc(func(code int) {
+				t.Errorf("Got exit with %d, want no exit", code)
+			})
+
+			defer func() {
+				r := recover()
+
+				if r != tt.wantPanic {
+					t.Errorf("Got panic %v, want %v", r, tt.wantPanic)
+				}
+
+				if buf.String() != tt.wantPanic {
+					t.Errorf("Got %s, want %s", buf.String(), tt.wantPanic)
+				}
+			}()
+
+			tt.print(l)
+		})
+	}
+}
@@ -24,3 +24,32 @@ func Copy(src, dst Fields) Fields {
 
 	return dst
 }
+
+// Flatten returns a copy of `f` where nested fields - `Fields`, or
+// `map[string]interface{}`, are flattened, with dotted keys, e.g.:
+// `{"a": {"b": 1}}` -> `{"a.b": 1}`.
+func Flatten(f Fields) Fields {
+	flattened := Fields{}
+
+	flatten("", f, flattened)
+
+	return flattened
+}
+
+// Recursively flattens `src` into `dst`, prefixing keys with `prefix`.
+func flatten(prefix string, src, dst Fields) {
+	for k, v := range src {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+
+		switch nested := v.(type) {
+		case Fields:
+			flatten(k, nested, dst)
+		case map[string]interface{}:
+			flatten(k, nested, dst)
+		default:
+			dst[k] = v
+		}
+	}
+}
@@ -75,3 +75,37 @@ func TestCopy(t *testing.T) {
 		})
 	}
 }
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Fields
+		want Fields
+	}{
+		{
+			name: "Should work",
+			f:    Fields{"a": 1},
+			want: Fields{"a": 1},
+		},
+		{
+			name: "Should work - nested",
+			f: Fields{
+				"a": 1,
+				"b": Fields{"c": 2, "d": map[string]interface{}{"e": 3}},
+			},
+			want: Fields{"a": 1, "b.c": 2, "b.d.e": 3},
+		},
+		{
+			name: "Should work - nil",
+			f:    nil,
+			want: Fields{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Flatten(tt.f); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Flatten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
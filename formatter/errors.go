@@ -0,0 +1,10 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import "errors"
+
+// ErrInvalidLogfmt is returned when parsing an invalid logfmt line.
+var ErrInvalidLogfmt = errors.New("invalid logfmt")
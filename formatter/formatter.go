@@ -2,6 +2,7 @@ package formatter
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -21,7 +22,9 @@ type IFormatter = processor.IProcessor
 // JSON is a JSON formatter. It automatically adds:
 // - Component name
 // - Level
-// - Timestamp (RFC3339).
+// - Timestamp (RFC3339)
+// - Caller, and function, if known
+// - Stack trace, if captured.
 func JSON() IFormatter {
 	return processor.New("JSON", func(m message.IMessage) error {
 		mM := map[string]interface{}{}
@@ -32,6 +35,27 @@ func JSON() IFormatter {
 		mM["timestamp"] = m.GetTimestamp().Format(time.RFC3339)
 		mM["message"] = m.GetContent().GetProcessed()
 
+		// Should only add the caller if known.
+		if caller := m.GetCaller(); caller.PC != 0 {
+			mM["caller"] = shared.ShortCaller(caller.File, caller.Line)
+			mM["function"] = caller.Function
+		}
+
+		// Should only add the stack trace if captured.
+		if st := m.GetStackTrace(); len(st) != 0 {
+			frames := make([]map[string]interface{}, 0, len(st))
+
+			for _, f := range st {
+				frames = append(frames, map[string]interface{}{
+					"function": f.Function,
+					"file":     f.File,
+					"line":     f.Line,
+				})
+			}
+
+			mM["stacktrace"] = frames
+		}
+
 		// Should only process fields if any.
 		if len(m.GetFields()) != 0 {
 			for k, v := range m.GetFields() {
@@ -45,10 +69,89 @@ func JSON() IFormatter {
 	})
 }
 
+// CompactJSON is a single line JSON formatter, suitable for NDJSON based
+// shippers. It automatically adds:
+// - Component name
+// - Output name
+// - Level
+// - Timestamp (default: RFC3339)
+// - Caller, and function, if known
+// - Stack trace, if captured.
+//
+// Keys are deterministically ordered: core keys first, then fields sorted by
+// key. Key names, and the timestamp format are configurable via `opts`, which
+// is optional. `error` values are encoded as their messages, `fmt.Stringer`
+// ones as strings. Lines are always terminated by a new line.
+func CompactJSON(opts *JSONOptions) IFormatter {
+	o := JSONOptions{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	o = o.withDefaults()
+
+	return processor.New("CompactJSON", func(m message.IMessage) error {
+		m.GetContent().SetProcessed(terminateLine(m, encodeCompactJSON(m, o)))
+
+		return nil
+	})
+}
+
+// GELF is a GELF 1.1 formatter, suitable for Graylog. It automatically adds:
+// - Host
+// - Short message (first line of the content), and full message, if
+// multi-line
+// - Timestamp (seconds since epoch, with milliseconds)
+// - Level, mapped to the syslog severity
+// - Component name, and output name, as additional fields
+// - Caller, and function, if known, as additional fields.
+//
+// Fields are flattened, with dotted keys, and added as additional fields - `_`
+// prefixed. Values other than numbers are encoded as strings. `opts` is
+// optional.
+func GELF(opts *GELFOptions) IFormatter {
+	o := GELFOptions{}
+
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.Host == "" {
+		o.Host, _ = os.Hostname()
+	}
+
+	return processor.New("GELF", func(m message.IMessage) error {
+		m.GetContent().SetProcessed(encodeGELF(m, o))
+
+		return nil
+	})
+}
+
+// Logfmt is a logfmt formatter. It automatically adds:
+// - Component name
+// - Output name
+// - Level
+// - Timestamp (RFC3339)
+// - Caller, if known.
+//
+// Values are quoted, and escaped if needed. Nested fields are flattened, with
+// dotted keys, and sorted by key. Lines are always terminated by a new line,
+// and can be parsed back with `ParseLogfmt`.
+func Logfmt() IFormatter {
+	return processor.New("Logfmt", func(m message.IMessage) error {
+		m.GetContent().SetProcessed(terminateLine(m, encodeLogfmt(m)))
+
+		return nil
+	})
+}
+
 // Text is a text formatter. It automatically adds:
 // - Component name
 // - Level
-// - Timestamp (RFC3339).
+// - Timestamp (RFC3339)
+// - Caller, if known
+// - Stack trace, if captured, as a multi-line block.
 func Text() IFormatter {
 	return processor.New("Text", func(m message.IMessage) error {
 		buf := new(strings.Builder)
@@ -63,6 +166,11 @@ func Text() IFormatter {
 		fmt.Fprintf(w, "timestamp=%s\t", m.GetTimestamp().Format(time.RFC3339))
 		fmt.Fprintf(w, "message=%s\t", m.GetContent().GetProcessed())
 
+		// Should only add the caller if known.
+		if caller := m.GetCaller(); caller.PC != 0 {
+			fmt.Fprintf(w, "caller=%s\t", shared.ShortCaller(caller.File, caller.Line))
+		}
+
 		// Should only process fields if any.
 		if len(m.GetFields()) != 0 {
 			for k, v := range m.GetFields() {
@@ -72,8 +180,32 @@ func Text() IFormatter {
 
 		w.Flush()
 
+		// Should only add the stack trace if captured. It's a block, similar to
+		// the one printed by `panic`, so it's added after the aligned columns.
+		if st := m.GetStackTrace(); len(st) != 0 {
+			buf.WriteString("\nstacktrace:")
+
+			for _, f := range st {
+				fmt.Fprintf(buf, "\n%s()\n\t%s:%d", f.Function, f.File, f.Line)
+			}
+		}
+
 		m.GetContent().SetProcessed(buf.String())
 
 		return nil
 	})
 }
+
+//////
+// Helpers.
+//////
+
+// Terminates line based formats with a new line. If the original content has
+// line break(s), they are restored after formatting instead.
+func terminateLine(m message.IMessage, content string) string {
+	if !strings.HasSuffix(m.GetContent().GetOriginal(), "\n") {
+		content += "\n"
+	}
+
+	return content
+}
@@ -1,8 +1,11 @@
 package formatter
 
 import (
+	"errors"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/saucelabs/sypl/fields"
 	"github.com/saucelabs/sypl/level"
@@ -87,3 +90,147 @@ func TestJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestCaller(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter IFormatter
+		want      []string
+	}{
+		{
+			name:      "Should work - Text",
+			formatter: Text(),
+			want:      []string{"caller=formatter_test.go:"},
+		},
+		{
+			name:      "Should work - JSON",
+			formatter: JSON(),
+			want:      []string{`"caller": "formatter_test.go:`, `"function": "`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := message.New(level.Info, shared.DefaultContentOutput)
+			m.SetCaller(runtime.Frame{PC: 1, File: "/a/formatter_test.go", Line: 12, Function: "formatter.TestCaller"})
+
+			if err := tt.formatter.Run(m); err != nil {
+				t.Errorf("Run() = %v, error %v", m, err)
+			}
+
+			for _, w := range tt.want {
+				if !strings.Contains(m.String(), w) {
+					t.Errorf("Run() = %s, missing %s", m.String(), w)
+				}
+			}
+		})
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter IFormatter
+		want      []string
+	}{
+		{
+			name:      "Should work - Text",
+			formatter: Text(),
+			want: []string{
+				"\nstacktrace:\nmain.a()\n\t/a/main.go:12\nmain.main()\n\t/a/main.go:20",
+			},
+		},
+		{
+			name:      "Should work - JSON",
+			formatter: JSON(),
+			want: []string{
+				`"stacktrace": [`,
+				`"function": "main.a"`,
+				`"file": "/a/main.go"`,
+				`"line": 20`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := message.New(level.Error, shared.DefaultContentOutput)
+			m.SetStackTrace([]runtime.Frame{
+				{PC: 1, File: "/a/main.go", Line: 12, Function: "main.a"},
+				{PC: 2, File: "/a/main.go", Line: 20, Function: "main.main"},
+			})
+
+			if err := tt.formatter.Run(m); err != nil {
+				t.Errorf("Run() = %v, error %v", m, err)
+			}
+
+			for _, w := range tt.want {
+				if !strings.Contains(m.String(), w) {
+					t.Errorf("Run() = %s, missing %s", m.String(), w)
+				}
+			}
+		})
+	}
+}
+
+type stringer struct{}
+
+func (s stringer) String() string { return "stringer" }
+
+func TestCompactJSON(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 51, 46, 89123456, time.UTC)
+
+	tests := []struct {
+		name    string
+		opts    *JSONOptions
+		content string
+		fields  fields.Fields
+		want    string
+	}{
+		{
+			name:    "Should work",
+			opts:    nil,
+			content: "contains <html> & \"quotes\"",
+			fields: fields.Fields{
+				"b":   1,
+				"a":   fields.Fields{"d": errors.New("nested error"), "c": true},
+				"err": errors.New("some error"),
+				"s":   stringer{},
+			},
+			want: `{"component":"component","output":"output","level":"info","timestamp":"2021-06-22T12:51:46Z","message":"contains <html> & \"quotes\"","a":{"c":true,"d":"nested error"},"b":1,"err":"some error","s":"stringer"}` + "\n",
+		},
+		{
+			name: "Should work - custom keys, and timestamp",
+			opts: &JSONOptions{
+				ComponentKey:       "logger",
+				LevelKey:           "severity",
+				MessageKey:         "msg",
+				OutputKey:          "sink",
+				TimestampKey:       "ts",
+				TimestampFormat:    time.RFC3339Nano,
+				TimestampPrecision: time.Millisecond,
+			},
+			content: "message\n",
+			fields:  fields.Fields{"msg": "should not override"},
+			want:    `{"logger":"component","sink":"output","severity":"info","ts":"2021-06-22T12:51:46.089Z","msg":"message"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := message.New(level.Info, tt.content)
+			m.SetComponentName("component")
+			m.SetOutputName("output")
+			m.SetTimestamp(ts)
+			m.SetFields(tt.fields)
+
+			// Mimics the output.
+			m.Strip()
+
+			if err := CompactJSON(tt.opts).Run(m); err != nil {
+				t.Errorf("CompactJSON() = %v, error %v", m, err)
+			}
+
+			if m.GetContent().GetProcessed() != tt.want {
+				t.Errorf("CompactJSON() = %s, want %s", m.GetContent().GetProcessed(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// GELFVersion is the GELF spec version.
+const GELFVersion = "1.1"
+
+// Characters not allowed in GELF additional field names.
+var gelfInvalidFieldNameChars = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFOptions are options for the `GELF` formatter.
+type GELFOptions struct {
+	// Host is the name of the host sending the message. Default is
+	// `os.Hostname()`.
+	Host string
+}
+
+// Encodes the message as a GELF 1.1 payload. Core keys first, then additional
+// fields sorted by key.
+func encodeGELF(m message.IMessage, o GELFOptions) string {
+	content := strings.TrimRight(m.GetContent().GetProcessed(), "\r\n")
+
+	// Short message is the first line, and it's required.
+	shortMessage, _, multiline := strings.Cut(content, "\n")
+
+	if shortMessage == "" {
+		shortMessage = "-"
+	}
+
+	pairs := []jsonPair{
+		{"version", GELFVersion},
+		{"host", o.Host},
+		{"short_message", shortMessage},
+	}
+
+	if multiline {
+		pairs = append(pairs, jsonPair{"full_message", content})
+	}
+
+	ts := m.GetTimestamp()
+
+	pairs = append(pairs,
+		jsonPair{"timestamp", json.Number(strconv.FormatFloat(float64(ts.UnixNano())/1e9, 'f', 3, 64))},
+		jsonPair{"level", m.GetLevel().SyslogSeverity()},
+		jsonPair{"_component", m.GetComponentName()},
+		jsonPair{"_output", m.GetOutputName()},
+	)
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		pairs = append(pairs,
+			jsonPair{"_caller", shared.ShortCaller(caller.File, caller.Line)},
+			jsonPair{"_function", caller.Function},
+		)
+	}
+
+	reserved := map[string]bool{}
+
+	for _, p := range pairs {
+		reserved[p.key] = true
+	}
+
+	f := fields.Flatten(m.GetFields())
+
+	additional := map[string]interface{}{}
+
+	for k, v := range f {
+		key := gelfFieldName(k)
+
+		if !reserved[key] {
+			additional[key] = gelfValue(v)
+		}
+	}
+
+	keys := make([]string, 0, len(additional))
+
+	for k := range additional {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		pairs = append(pairs, jsonPair{k, additional[k]})
+	}
+
+	buf := new(bytes.Buffer)
+
+	buf.WriteByte('{')
+
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeJSONValue(buf, p.key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, p.value)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.String()
+}
+
+// Returns the GELF additional field name: `_` prefixed, with disallowed
+// characters replaced by `_`. `_id` is reserved, thus prefixed again.
+func gelfFieldName(k string) string {
+	k = "_" + gelfInvalidFieldNameChars.ReplaceAllString(k, "_")
+
+	if k == "_id" {
+		k = "__id"
+	}
+
+	return k
+}
+
+// Converts a field value to a GELF additional field value, which is either a
+// number, or a string.
+func gelfValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	case nil:
+		return "null"
+	case string:
+		return value
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
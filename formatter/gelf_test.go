@@ -0,0 +1,67 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+func TestGELF(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 51, 46, 89123456, time.UTC)
+
+	tests := []struct {
+		name    string
+		level   level.Level
+		content string
+		fields  fields.Fields
+		want    string
+	}{
+		{
+			name:    "Should work",
+			level:   level.Error,
+			content: "message",
+			fields: fields.Fields{
+				"b":    1.5,
+				"a":    fields.Fields{"ok": true, "err": errors.New("nested error")},
+				"id":   "reserved",
+				"bad$": "key",
+			},
+			want: `{"version":"1.1","host":"host","short_message":"message","timestamp":1624366306.089,"level":3,"_component":"component","_output":"output","__id":"reserved","_a.err":"nested error","_a.ok":"true","_b":1.5,"_bad_":"key"}`,
+		},
+		{
+			name:    "Should work - multi-line",
+			level:   level.Fatal,
+			content: "first line\nsecond line\n",
+			fields:  nil,
+			want:    `{"version":"1.1","host":"host","short_message":"first line","full_message":"first line\nsecond line","timestamp":1624366306.089,"level":2,"_component":"component","_output":"output"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := message.New(tt.level, tt.content)
+			m.SetComponentName("component")
+			m.SetOutputName("output")
+			m.SetTimestamp(ts)
+			m.SetFields(tt.fields)
+
+			// Mimics the output.
+			m.Strip()
+
+			if err := GELF(&GELFOptions{Host: "host"}).Run(m); err != nil {
+				t.Errorf("GELF() = %v, error %v", m, err)
+			}
+
+			if m.GetContent().GetProcessed() != tt.want {
+				t.Errorf("GELF() = %s, want %s", m.GetContent().GetProcessed(), tt.want)
+			}
+		})
+	}
+}
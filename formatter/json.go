@@ -0,0 +1,221 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// JSONOptions are options for the `CompactJSON` formatter. Empty keys, and
+// formats fallback to the defaults.
+type JSONOptions struct {
+	// ComponentKey is the key of the component name. Default is `component`.
+	ComponentKey string
+
+	// LevelKey is the key of the level. Default is `level`.
+	LevelKey string
+
+	// MessageKey is the key of the message. Default is `message`.
+	MessageKey string
+
+	// OutputKey is the key of the output name. Default is `output`.
+	OutputKey string
+
+	// TimestampKey is the key of the timestamp. Default is `timestamp`.
+	TimestampKey string
+
+	// TimestampFormat is the timestamp format. Default is `time.RFC3339`.
+	TimestampFormat string
+
+	// TimestampPrecision truncates the timestamp to the specified precision,
+	// e.g.: `time.Millisecond`. Default is no truncation. Note that the format
+	// should be able to represent it, e.g.: `time.RFC3339Nano`.
+	TimestampPrecision time.Duration
+}
+
+// Sets defaults for empty options.
+func (o JSONOptions) withDefaults() JSONOptions {
+	if o.ComponentKey == "" {
+		o.ComponentKey = "component"
+	}
+
+	if o.LevelKey == "" {
+		o.LevelKey = "level"
+	}
+
+	if o.MessageKey == "" {
+		o.MessageKey = "message"
+	}
+
+	if o.OutputKey == "" {
+		o.OutputKey = "output"
+	}
+
+	if o.TimestampKey == "" {
+		o.TimestampKey = "timestamp"
+	}
+
+	if o.TimestampFormat == "" {
+		o.TimestampFormat = time.RFC3339
+	}
+
+	return o
+}
+
+// Key-value pair, keeping keys order.
+type jsonPair struct {
+	key   string
+	value interface{}
+}
+
+// Encodes the message as a single line JSON object. Core keys first, then
+// fields sorted by key. Fields can't override core keys.
+func encodeCompactJSON(m message.IMessage, o JSONOptions) string {
+	ts := m.GetTimestamp()
+
+	if o.TimestampPrecision > 0 {
+		ts = ts.Truncate(o.TimestampPrecision)
+	}
+
+	pairs := []jsonPair{
+		{o.ComponentKey, m.GetComponentName()},
+		{o.OutputKey, m.GetOutputName()},
+		{o.LevelKey, strings.ToLower(m.GetLevel().String())},
+		{o.TimestampKey, ts.Format(o.TimestampFormat)},
+		{o.MessageKey, m.GetContent().GetProcessed()},
+	}
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		pairs = append(pairs,
+			jsonPair{"caller", shared.ShortCaller(caller.File, caller.Line)},
+			jsonPair{"function", caller.Function},
+		)
+	}
+
+	// Should only add the stack trace if captured.
+	if st := m.GetStackTrace(); len(st) != 0 {
+		frames := make([]map[string]interface{}, 0, len(st))
+
+		for _, f := range st {
+			frames = append(frames, map[string]interface{}{
+				"function": f.Function,
+				"file":     f.File,
+				"line":     f.Line,
+			})
+		}
+
+		pairs = append(pairs, jsonPair{"stacktrace", frames})
+	}
+
+	reserved := map[string]bool{}
+
+	for _, p := range pairs {
+		reserved[p.key] = true
+	}
+
+	keys := make([]string, 0, len(m.GetFields()))
+
+	for k := range m.GetFields() {
+		if !reserved[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		pairs = append(pairs, jsonPair{k, m.GetFields()[k]})
+	}
+
+	buf := new(bytes.Buffer)
+
+	buf.WriteByte('{')
+
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeJSONValue(buf, p.key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, jsonValue(p.value))
+	}
+
+	buf.WriteByte('}')
+
+	return buf.String()
+}
+
+// Writes the JSON encoded value to the buffer, without escaping HTML, and
+// without the trailing new line. Values which can't be encoded are written as
+// strings.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	b := new(bytes.Buffer)
+
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+
+		_ = enc.Encode(fmt.Sprintf("%+v", v))
+	}
+
+	buf.Write(bytes.TrimRight(b.Bytes(), "\n"))
+}
+
+// Converts values to be properly encoded:
+// - `error`s are encoded as their messages
+// - `fmt.Stringer`s are encoded as strings, unless they know how to encode
+// themselves, e.g.: `time.Time`
+// - Nested fields are converted recursively.
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return value.Error()
+	case json.Marshaler, encoding.TextMarshaler:
+		return value
+	case fmt.Stringer:
+		return value.String()
+	case fields.Fields:
+		return jsonMap(value)
+	case map[string]interface{}:
+		return jsonMap(value)
+	case []interface{}:
+		s := make([]interface{}, 0, len(value))
+
+		for _, e := range value {
+			s = append(s, jsonValue(e))
+		}
+
+		return s
+	default:
+		return value
+	}
+}
+
+// Converts map values, see `jsonValue`.
+func jsonMap(m map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+
+	for k, v := range m {
+		converted[k] = jsonValue(v)
+	}
+
+	return converted
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// Encodes the message as a logfmt line. Core keys first, then fields -
+// flattened, sorted by key. Fields can't override core keys.
+func encodeLogfmt(m message.IMessage) string {
+	pairs := [][2]string{
+		{"component", m.GetComponentName()},
+		{"output", m.GetOutputName()},
+		{"level", strings.ToLower(m.GetLevel().String())},
+		{"timestamp", m.GetTimestamp().Format(time.RFC3339)},
+		{"message", m.GetContent().GetProcessed()},
+	}
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		pairs = append(pairs, [2]string{"caller", shared.ShortCaller(caller.File, caller.Line)})
+	}
+
+	reserved := map[string]bool{}
+
+	for _, p := range pairs {
+		reserved[p[0]] = true
+	}
+
+	f := fields.Flatten(m.GetFields())
+
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		if !reserved[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		pairs = append(pairs, [2]string{k, LogfmtString(f[k])})
+	}
+
+	buf := new(strings.Builder)
+
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+
+		buf.WriteString(LogfmtKey(p[0]))
+		buf.WriteByte('=')
+		buf.WriteString(logfmtQuote(p[1]))
+	}
+
+	return buf.String()
+}
+
+// LogfmtValue encodes a field value as a logfmt value, quoted, and escaped if
+// needed, see `LogfmtString`.
+func LogfmtValue(v interface{}) string {
+	return logfmtQuote(LogfmtString(v))
+}
+
+// LogfmtString converts a field value to string, as the `Logfmt` formatter
+// does. `error`s are converted to their messages, and `nil` to `null`.
+func LogfmtString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Returns true if `r` isn't allowed in a logfmt key, or unquoted value.
+func needsLogfmtQuoting(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r)
+}
+
+// LogfmtKey sanitizes `k` to be used as a logfmt key. Disallowed characters -
+// spaces, `=`, `"`, and non-printable ones, are replaced by `_`.
+func LogfmtKey(k string) string {
+	if k == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if needsLogfmtQuoting(r) {
+			return '_'
+		}
+
+		return r
+	}, k)
+}
+
+// Quotes, and escapes `v` if needed to be used as a logfmt value.
+// Empty values, and values containing spaces, `=`, `"`, or non-printable
+// characters are quoted.
+func logfmtQuote(v string) string {
+	if v != "" && strings.IndexFunc(v, needsLogfmtQuoting) == -1 {
+		return v
+	}
+
+	return strconv.Quote(v)
+}
+
+// ParseLogfmt parses a logfmt line, as the one produced by the `Logfmt`
+// formatter, returning keys, and their unquoted values. Keys without value
+// have an empty value. Duplicated keys, last one wins.
+func ParseLogfmt(line string) (map[string]string, error) {
+	parsed := map[string]string{}
+
+	line = strings.TrimRight(line, "\r\n")
+
+	for i := 0; i < len(line); {
+		// Skip spaces between pairs.
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+
+			continue
+		}
+
+		// Key.
+		start := i
+
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			if line[i] == '"' {
+				return nil, fmt.Errorf(`%w: unexpected quote in key at %d`, ErrInvalidLogfmt, i)
+			}
+
+			i++
+		}
+
+		key := line[start:i]
+
+		if key == "" {
+			return nil, fmt.Errorf(`%w: missing key at %d`, ErrInvalidLogfmt, i)
+		}
+
+		// Key without value.
+		if i >= len(line) || line[i] != '=' {
+			parsed[key] = ""
+
+			continue
+		}
+
+		// Skip `=`.
+		i++
+
+		// Quoted value.
+		if i < len(line) && line[i] == '"' {
+			end, err := quotedValueEnd(line, i)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := strconv.Unquote(line[i:end])
+			if err != nil {
+				return nil, fmt.Errorf(`%w: invalid quoted value at %d: %s`, ErrInvalidLogfmt, i, err)
+			}
+
+			parsed[key] = value
+			i = end
+
+			continue
+		}
+
+		// Bare value.
+		start = i
+
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			if line[i] == '"' || line[i] == '=' {
+				return nil, fmt.Errorf(`%w: unexpected %q in value at %d`, ErrInvalidLogfmt, line[i], i)
+			}
+
+			i++
+		}
+
+		parsed[key] = line[start:i]
+	}
+
+	return parsed, nil
+}
+
+// Returns the index after the closing quote of the quoted value starting at
+// `start`.
+func quotedValueEnd(line string, start int) (int, error) {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			// Skip the escaped character.
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf(`%w: unterminated quoted value at %d`, ErrInvalidLogfmt, start)
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package formatter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+func TestLogfmt(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 51, 46, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		content    string
+		fields     fields.Fields
+		want       string
+		wantParsed map[string]string
+	}{
+		{
+			name:    "Should work",
+			content: "message",
+			fields:  fields.Fields{"b": 1, "a": true},
+			want:    "component=component output=Output level=info timestamp=2021-06-22T12:51:46Z message=message a=true b=1\n",
+			wantParsed: map[string]string{
+				"component": "component",
+				"output":    "Output",
+				"level":     "info",
+				"timestamp": "2021-06-22T12:51:46Z",
+				"message":   "message",
+				"a":         "true",
+				"b":         "1",
+			},
+		},
+		{
+			name:    "Should work - quoting, nested fields, and keys sanitization",
+			content: "a \"quoted\" message=1\n",
+			fields: fields.Fields{
+				"err":     errors.New("some\terror"),
+				"empty":   "",
+				"nil":     nil,
+				"user":    fields.Fields{"name": "John Doe", "id": 1},
+				"bad key": "v",
+				"message": "should not override",
+			},
+			want: `component=component output=Output level=info timestamp=2021-06-22T12:51:46Z message="a \"quoted\" message=1" bad_key=v empty="" err="some\terror" nil=null user.id=1 user.name="John Doe"`,
+			wantParsed: map[string]string{
+				"component": "component",
+				"output":    "Output",
+				"level":     "info",
+				"timestamp": "2021-06-22T12:51:46Z",
+				"message":   `a "quoted" message=1`,
+				"bad_key":   "v",
+				"empty":     "",
+				"err":       "some\terror",
+				"nil":       "null",
+				"user.id":   "1",
+				"user.name": "John Doe",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := message.New(level.Info, tt.content)
+			m.SetComponentName("component")
+			m.SetOutputName("Output")
+			m.SetTimestamp(ts)
+			m.SetFields(tt.fields)
+
+			// Mimics the output.
+			m.Strip()
+
+			if err := Logfmt().Run(m); err != nil {
+				t.Errorf("Logfmt() = %v, error %v", m, err)
+			}
+
+			got := m.GetContent().GetProcessed()
+
+			if got != tt.want {
+				t.Errorf("Logfmt() = %s, want %s", got, tt.want)
+			}
+
+			parsed, err := ParseLogfmt(got)
+			if err != nil {
+				t.Fatalf("ParseLogfmt() error %v", err)
+			}
+
+			if !reflect.DeepEqual(parsed, tt.wantParsed) {
+				t.Errorf("ParseLogfmt() = %v, want %v", parsed, tt.wantParsed)
+			}
+		})
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Should work",
+			line: `a=1  b="2 3" c d=`,
+			want: map[string]string{"a": "1", "b": "2 3", "c": "", "d": ""},
+		},
+		{
+			name:    "Should fail - unterminated quoted value",
+			line:    `a="1`,
+			wantErr: true,
+		},
+		{
+			name:    "Should fail - missing key",
+			line:    `=1`,
+			wantErr: true,
+		},
+		{
+			name:    "Should fail - unquoted quote",
+			line:    `a=1"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogfmt(tt.line)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLogfmt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLogfmt) {
+					t.Errorf("ParseLogfmt() error = %v, want %v", err, ErrInvalidLogfmt)
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLogfmt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,19 @@
 module github.com/saucelabs/sypl
 
-go 1.19
+go 1.21
 
 require (
 	github.com/acarl005/stripansi v0.0.0-20180116102854-5a71ef0e047d
 	github.com/emirpasic/gods v1.18.1
 	github.com/fatih/color v1.13.0
 	github.com/go-test/deep v1.0.8
+	github.com/golang/snappy v0.0.4
 	github.com/google/uuid v1.2.0
 	github.com/saucelabs/lumberjack/v3 v3.0.3
 	github.com/spf13/afero v1.9.2
 	github.com/stretchr/testify v1.7.1
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
+	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
 )
 
 require (
@@ -19,7 +21,6 @@ require (
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	golang.org/x/text v0.3.4 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
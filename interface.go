@@ -5,6 +5,8 @@
 package sypl
 
 import (
+	"context"
+
 	"github.com/saucelabs/sypl/fields"
 	"github.com/saucelabs/sypl/level"
 	"github.com/saucelabs/sypl/message"
@@ -19,6 +21,11 @@ type IBasePrinter interface {
 	// full-control over the message. Use `New` to create the message.
 	PrintMessage(messages ...message.IMessage) ISypl
 
+	// PrintMessageOrdered prints messages like `PrintMessage`, but each output
+	// writes them in the specified order, regardless of the logger's ordering
+	// mode.
+	PrintMessageOrdered(messages ...message.IMessage) ISypl
+
 	// PrintWithOptions is a more flexible way of printing, allowing to specify
 	// a few message's options. For full-control over the message is possible
 	// via `PrintMessage`.
@@ -108,6 +115,25 @@ type ILeveledPrinter interface {
 	// os.Exit(1).
 	Fatalln(args ...interface{}) ISypl
 
+	// Panic prints @ the Fatal level - tagged with `PanicTag`, without
+	// exiting, flushes outputs, and panics with the non-processed content.
+	Panic(args ...interface{})
+
+	// Panicf prints according with the format @ the Fatal level - tagged with
+	// `PanicTag`, without exiting, flushes outputs, and panics with the
+	// non-processed content.
+	Panicf(format string, args ...interface{})
+
+	// Paniclnf prints according with the format @ the Fatal level - tagged
+	// with `PanicTag`, without exiting, also adding a new line to the end,
+	// flushes outputs, and panics with the non-processed content.
+	Paniclnf(format string, args ...interface{})
+
+	// Panicln prints, also adding a new line to the end @ the Fatal level -
+	// tagged with `PanicTag`, without exiting, flushes outputs, and panics
+	// with the non-processed content.
+	Panicln(args ...interface{})
+
 	// Error prints @ the Error level.
 	Error(args ...interface{}) ISypl
 
@@ -190,11 +216,97 @@ type ILeveledPrinter interface {
 	Traceln(args ...interface{}) ISypl
 }
 
+// IContextPrinter specifies the context-aware printers. Fields are extracted
+// from the context by the registered `ContextExtractor`s.
+type IContextPrinter interface {
+	// PrintWithContext prints, adding fields extracted from the context by the
+	// registered extractors.
+	PrintWithContext(ctx context.Context, l level.Level, args ...interface{}) ISypl
+
+	// PrintfWithContext prints according with the specified format, adding
+	// fields extracted from the context by the registered extractors.
+	PrintfWithContext(ctx context.Context, l level.Level, format string, args ...interface{}) ISypl
+
+	// PrintlnfWithContext prints according with the specified format, also
+	// adding a new line to the end, and fields extracted from the context by
+	// the registered extractors.
+	PrintlnfWithContext(ctx context.Context, l level.Level, format string, args ...interface{}) ISypl
+
+	// PrintlnWithContext prints, also adding a new line to the end, and fields
+	// extracted from the context by the registered extractors.
+	PrintlnWithContext(ctx context.Context, l level.Level, args ...interface{}) ISypl
+
+	// PrintWithOptionsAndContext is like `PrintWithOptions`, adding fields
+	// extracted from the context by the registered extractors. Options' fields
+	// have precedence.
+	PrintWithOptionsAndContext(ctx context.Context, o *options.Options, l level.Level, args ...interface{}) ISypl
+
+	// PrintfWithOptionsAndContext is like `PrintfWithOptions`, adding fields
+	// extracted from the context by the registered extractors. Options' fields
+	// have precedence.
+	PrintfWithOptionsAndContext(
+		ctx context.Context,
+		o *options.Options,
+		l level.Level,
+		format string,
+		args ...interface{},
+	) ISypl
+
+	// FatalContext prints like `Fatal`, adding fields extracted from the
+	// context, and exit with os.Exit(1).
+	FatalContext(ctx context.Context, args ...interface{}) ISypl
+
+	// FatalfContext prints like `Fatalf`, adding fields extracted from the
+	// context, and exit with os.Exit(1).
+	FatalfContext(ctx context.Context, format string, args ...interface{}) ISypl
+
+	// ErrorContext prints @ the Error level, adding fields extracted from the
+	// context.
+	ErrorContext(ctx context.Context, args ...interface{}) ISypl
+
+	// ErrorfContext prints according with the format @ the Error level, adding
+	// fields extracted from the context.
+	ErrorfContext(ctx context.Context, format string, args ...interface{}) ISypl
+
+	// InfoContext prints @ the Info level, adding fields extracted from the
+	// context.
+	InfoContext(ctx context.Context, args ...interface{}) ISypl
+
+	// InfofContext prints according with the format @ the Info level, adding
+	// fields extracted from the context.
+	InfofContext(ctx context.Context, format string, args ...interface{}) ISypl
+
+	// WarnContext prints @ the Warn level, adding fields extracted from the
+	// context.
+	WarnContext(ctx context.Context, args ...interface{}) ISypl
+
+	// WarnfContext prints according with the format @ the Warn level, adding
+	// fields extracted from the context.
+	WarnfContext(ctx context.Context, format string, args ...interface{}) ISypl
+
+	// DebugContext prints @ the Debug level, adding fields extracted from the
+	// context.
+	DebugContext(ctx context.Context, args ...interface{}) ISypl
+
+	// DebugfContext prints according with the format @ the Debug level, adding
+	// fields extracted from the context.
+	DebugfContext(ctx context.Context, format string, args ...interface{}) ISypl
+
+	// TraceContext prints @ the Trace level, adding fields extracted from the
+	// context.
+	TraceContext(ctx context.Context, args ...interface{}) ISypl
+
+	// TracefContext prints according with the format @ the Trace level, adding
+	// fields extracted from the context.
+	TracefContext(ctx context.Context, format string, args ...interface{}) ISypl
+}
+
 // IPrinters is all available printers.
 type IPrinters interface {
 	IBasePrinter
 	IBasicPrinter
 	IConvenientPrinter
+	IContextPrinter
 	ILeveledPrinter
 }
 
@@ -220,6 +332,44 @@ type ISypl interface {
 	// String interface.
 	String() string
 
+	// AddContextExtractors adds one or more context extractors. They're used
+	// by the context-aware printers.
+	AddContextExtractors(extractors ...ContextExtractor) ISypl
+
+	// GetContextExtractors returns registered context extractors.
+	GetContextExtractors() []ContextExtractor
+
+	// GetExitFunc returns the exit function.
+	GetExitFunc() ExitFunc
+
+	// SetExitFunc sets the function called, with the exit code, after printing
+	// `Fatal` messages. Default is `os.Exit`.
+	SetExitFunc(f ExitFunc) ISypl
+
+	// AddExitHooks adds one or more exit hooks. They run, in the order they
+	// were added, before outputs are flushed, and before exiting.
+	AddExitHooks(hooks ...ExitHook) ISypl
+
+	// GetExitHooks returns registered exit hooks.
+	GetExitHooks() []ExitHook
+
+	// GetOrdered returns true if the logger is in the ordering mode.
+	GetOrdered() bool
+
+	// SetOrdered sets the ordering mode. If set, messages printed in a single
+	// call, e.g.: `PrintMessage(m1, m2, m3)`, are written by each output in
+	// the specified order (FIFO). Outputs still write concurrently. Default is
+	// `false`, where each message is processed concurrently.
+	SetOrdered(ordered bool) ISypl
+
+	// GetStackTraceLevel returns the stack trace level.
+	GetStackTraceLevel() level.Level
+
+	// SetStackTraceLevel sets the stack trace level. A stack trace is captured
+	// for messages at, or above (more severe) the specified level. Default is
+	// `None`, meaning disabled.
+	SetStackTraceLevel(l level.Level) ISypl
+
 	// GetFields returns the global registered fields.
 	GetFields() fields.Fields
 
@@ -233,6 +383,12 @@ type ISypl interface {
 	// SetMaxLevel sets the `maxLevel` of all outputs.
 	SetMaxLevel(l level.Level) ISypl
 
+	// GetMinLevel returns the `minLevel` of all outputs.
+	GetMinLevel() map[string]level.Level
+
+	// SetMinLevel sets the `minLevel` of all outputs.
+	SetMinLevel(l level.Level) ISypl
+
 	// AddOutputs adds one or more outputs.
 	AddOutputs(outputs ...output.IOutput) ISypl
 
@@ -248,6 +404,13 @@ type ISypl interface {
 	// GetOutputsNames returns the names of the registered outputs.
 	GetOutputsNames() []string
 
+	// Flush flushes all outputs.
+	Flush() error
+
+	// Close flushes, and closes all outputs. Writing to a closed output
+	// returns `output.ErrOutputClosed`.
+	Close() error
+
 	// New creates a child logger.
 	New(name string) *Sypl
 
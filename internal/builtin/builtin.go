@@ -183,6 +183,20 @@ func (l *Builtin) OutputBuiltin(calldepth int, s string) error {
 	return err
 }
 
+// OutputCaller is like OutputBuiltin, but the file name and line number of the
+// caller are the specified ones, instead of recovered via runtime.Caller. It's
+// useful when the caller is known beforehand, and the call depth is unknown.
+func (l *Builtin) OutputCaller(file string, line int, s string) error {
+	now := time.Now() // get this early.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = l.buf[:0]
+	l.formatHeader(&l.buf, now, file, line)
+	l.buf = append(l.buf, s...)
+	_, err := l.out.Write(l.buf)
+	return err
+}
+
 // Printf calls l.OutputBuiltin to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 func (l *Builtin) Printf(format string, v ...interface{}) {
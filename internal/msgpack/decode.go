@@ -0,0 +1,236 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package msgpack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrInvalid is returned when decoding invalid MessagePack.
+var ErrInvalid = errors.New("invalid msgpack")
+
+// Decoder decodes MessagePack values from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// Decode decodes the next value. Values are decoded as:
+// - nil, bool, string, and float64
+// - `int64` for negative integers, `uint64` otherwise
+// - `[]byte` for binaries
+// - `[]interface{}` for arrays
+// - `map[string]interface{}` for maps - non-string keys are formatted
+// - `Ext` for extensions.
+func (d *Decoder) Decode() (interface{}, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return uint64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readLength(c - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.readN(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readLength(c - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.readUint(1 << (c - 0xcc))
+	case 0xd0:
+		v, err := d.readUint(1)
+
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := d.readUint(2)
+
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := d.readUint(4)
+
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := d.readUint(8)
+
+		return int64(v), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readLength(c - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readLength(c - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.readLength(c - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeMap(n)
+	}
+
+	return nil, fmt.Errorf("%w: unknown type 0x%x", ErrInvalid, c)
+}
+
+//////
+// Helpers.
+//////
+
+// Reads a length: 0 means uint8, 1 uint16, and 2 uint32.
+func (d *Decoder) readLength(size byte) (int, error) {
+	v, err := d.readUint(1 << size)
+
+	return int(v), err
+}
+
+// Reads a big endian unsigned integer of `n` bytes.
+func (d *Decoder) readUint(n int) (uint64, error) {
+	b, err := d.readN(n)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// Reads `n` bytes.
+func (d *Decoder) readN(n int) ([]byte, error) {
+	b := make([]byte, n)
+
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (d *Decoder) decodeString(n int) (interface{}, error) {
+	b, err := d.readN(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (d *Decoder) decodeExt(n int) (interface{}, error) {
+	typ, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := d.readN(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return Ext{Type: int8(typ), Data: data}, nil
+}
+
+func (d *Decoder) decodeArray(n int) (interface{}, error) {
+	a := make([]interface{}, 0, n)
+
+	for i := 0; i < n; i++ {
+		v, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		a = append(a, v)
+	}
+
+	return a, nil
+}
+
+func (d *Decoder) decodeMap(n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		k, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+
+		m[key] = v
+	}
+
+	return m, nil
+}
+
+//////
+// Factory.
+//////
+
+// NewDecoder is the `Decoder` factory.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
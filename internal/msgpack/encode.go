@@ -0,0 +1,268 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package msgpack implements the subset of MessagePack needed to talk to
+// Fluentd, and compatible agents, without external dependencies.
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// EventTimeExtType is the Fluentd's `EventTime` extension type.
+const EventTimeExtType = 0
+
+// Ext is an extension value.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// AppendNil appends nil.
+func AppendNil(b []byte) []byte {
+	return append(b, 0xc0)
+}
+
+// AppendBool appends a bool.
+func AppendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 0xc3)
+	}
+
+	return append(b, 0xc2)
+}
+
+// AppendInt appends a signed integer, using the smallest representation.
+func AppendInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return AppendUint(b, uint64(v))
+	}
+
+	switch {
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+// AppendUint appends an unsigned integer, using the smallest representation.
+func AppendUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= math.MaxInt8:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+	}
+}
+
+// AppendFloat64 appends a float64.
+func AppendFloat64(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+}
+
+// AppendString appends a string.
+func AppendString(b []byte, v string) []byte {
+	n := len(v)
+
+	switch {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+
+	return append(b, v...)
+}
+
+// AppendBinary appends a binary.
+func AppendBinary(b []byte, v []byte) []byte {
+	n := len(v)
+
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+
+	return append(b, v...)
+}
+
+// AppendArrayHeader appends the header of an array of `n` elements, which
+// should be appended next.
+func AppendArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+// AppendMapHeader appends the header of a map of `n` key-value pairs, which
+// should be appended next.
+func AppendMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// AppendExt appends an extension value.
+func AppendExt(b []byte, typ int8, data []byte) []byte {
+	n := len(data)
+
+	switch n {
+	case 1:
+		b = append(b, 0xd4)
+	case 2:
+		b = append(b, 0xd5)
+	case 4:
+		b = append(b, 0xd6)
+	case 8:
+		b = append(b, 0xd7)
+	case 16:
+		b = append(b, 0xd8)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			b = append(b, 0xc7, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xc8), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xc9), uint32(n))
+		}
+	}
+
+	b = append(b, byte(typ))
+
+	return append(b, data...)
+}
+
+// AppendEventTime appends `t` as a Fluentd's `EventTime`: seconds, and
+// nanoseconds, with nanosecond precision.
+func AppendEventTime(b []byte, t time.Time) []byte {
+	data := make([]byte, 0, 8)
+	data = binary.BigEndian.AppendUint32(data, uint32(t.Unix()))
+	data = binary.BigEndian.AppendUint32(data, uint32(t.Nanosecond()))
+
+	return AppendExt(b, EventTimeExtType, data)
+}
+
+// AppendValue appends `v`, converting it as needed:
+// - `error`s are appended as their messages
+// - `fmt.Stringer`s are appended as strings
+// - `time.Time` is appended as a RFC3339 string, with nanoseconds
+// - Maps are appended with keys sorted, for deterministic output
+// - Unsupported values are appended as strings, see `fmt.Sprint`.
+func AppendValue(b []byte, v interface{}) []byte {
+	switch value := v.(type) {
+	case nil:
+		return AppendNil(b)
+	case bool:
+		return AppendBool(b, value)
+	case int:
+		return AppendInt(b, int64(value))
+	case int8:
+		return AppendInt(b, int64(value))
+	case int16:
+		return AppendInt(b, int64(value))
+	case int32:
+		return AppendInt(b, int64(value))
+	case int64:
+		return AppendInt(b, value)
+	case uint:
+		return AppendUint(b, uint64(value))
+	case uint8:
+		return AppendUint(b, uint64(value))
+	case uint16:
+		return AppendUint(b, uint64(value))
+	case uint32:
+		return AppendUint(b, uint64(value))
+	case uint64:
+		return AppendUint(b, value)
+	case float32:
+		return AppendFloat64(b, float64(value))
+	case float64:
+		return AppendFloat64(b, value)
+	case string:
+		return AppendString(b, value)
+	case []byte:
+		return AppendBinary(b, value)
+	case Ext:
+		return AppendExt(b, value.Type, value.Data)
+	case time.Time:
+		return AppendString(b, value.Format(time.RFC3339Nano))
+	case error:
+		return AppendString(b, value.Error())
+	case fmt.Stringer:
+		return AppendString(b, value.String())
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+
+		keys := make([]string, 0, rv.Len())
+
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+
+		sort.Strings(keys)
+
+		b = AppendMapHeader(b, len(keys))
+
+		for _, k := range keys {
+			b = AppendString(b, k)
+			b = AppendValue(b, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface())
+		}
+
+		return b
+	case reflect.Slice, reflect.Array:
+		b = AppendArrayHeader(b, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			b = AppendValue(b, rv.Index(i).Interface())
+		}
+
+		return b
+	}
+
+	return AppendString(b, fmt.Sprint(v))
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendValue(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want []byte
+	}{
+		{name: "Should work - nil", v: nil, want: []byte{0xc0}},
+		{name: "Should work - bool", v: true, want: []byte{0xc3}},
+		{name: "Should work - positive fixint", v: 1, want: []byte{0x01}},
+		{name: "Should work - negative fixint", v: -1, want: []byte{0xff}},
+		{name: "Should work - uint16", v: 256, want: []byte{0xcd, 0x01, 0x00}},
+		{name: "Should work - int8", v: -33, want: []byte{0xd0, 0xdf}},
+		{name: "Should work - fixstr", v: "a", want: []byte{0xa1, 'a'}},
+		{name: "Should work - error", v: errors.New("a"), want: []byte{0xa1, 'a'}},
+		{name: "Should work - bin", v: []byte{1}, want: []byte{0xc4, 0x01, 0x01}},
+		{
+			name: "Should work - map, sorted keys",
+			v:    map[string]interface{}{"b": 2, "a": 1},
+			want: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02},
+		},
+		{
+			name: "Should work - array",
+			v:    []string{"a"},
+			want: []byte{0x91, 0xa1, 'a'},
+		},
+		{
+			name: "Should work - event time",
+			v:    Ext{Type: EventTimeExtType, Data: []byte{0, 0, 0, 1, 0, 0, 0, 2}},
+			want: []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendValue(nil, tt.v); !bytes.Equal(got, tt.want) {
+				t.Errorf("Got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendEventTime(t *testing.T) {
+	got := AppendEventTime(nil, time.Unix(1, 2))
+
+	want := []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Got %x, want %x", got, want)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	long := strings.Repeat("a", 300)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want interface{}
+	}{
+		{name: "Should work - nil", v: nil, want: nil},
+		{name: "Should work - bool", v: false, want: false},
+		{name: "Should work - uint", v: uint64(math.MaxUint32 + 1), want: uint64(math.MaxUint32 + 1)},
+		{name: "Should work - int", v: int64(math.MinInt32), want: int64(math.MinInt32)},
+		{name: "Should work - float", v: 1.5, want: 1.5},
+		{name: "Should work - long string", v: long, want: long},
+		{name: "Should work - bin", v: []byte{1, 2}, want: []byte{1, 2}},
+		{
+			name: "Should work - nested",
+			v:    map[string]interface{}{"a": []interface{}{"b", 1}, "c": map[string]interface{}{"d": true}},
+			want: map[string]interface{}{"a": []interface{}{"b", uint64(1)}, "c": map[string]interface{}{"d": true}},
+		},
+		{
+			name: "Should work - ext",
+			v:    Ext{Type: 1, Data: []byte{1, 2, 3}},
+			want: Ext{Type: 1, Data: []byte{1, 2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDecoder(bytes.NewReader(AppendValue(nil, tt.v))).Decode()
+			if err != nil {
+				t.Fatalf("Decode failed: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	if _, err := NewDecoder(bytes.NewReader([]byte{0xc1})).Decode(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Got %v, want %v", err, ErrInvalid)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package protobuf implements the subset of the protocol buffers wire format
+// needed to encode messages sent by outputs, without generated code.
+package protobuf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Wire types.
+const (
+	WireVarint  = 0
+	WireFixed64 = 1
+	WireBytes   = 2
+	WireFixed32 = 5
+)
+
+// AppendVarint appends `v` as a base 128 varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+// AppendTag appends the key of the field `num`, of the wire type `wireType`.
+func AppendTag(b []byte, num int, wireType int) []byte {
+	return AppendVarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+// AppendVarintField appends the varint field `num`. Zero values are omitted.
+func AppendVarintField(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	return AppendVarint(AppendTag(b, num, WireVarint), v)
+}
+
+// AppendFixed32Field appends the fixed32 field `num`. Zero values are omitted.
+func AppendFixed32Field(b []byte, num int, v uint32) []byte {
+	if v == 0 {
+		return b
+	}
+
+	return binary.LittleEndian.AppendUint32(AppendTag(b, num, WireFixed32), v)
+}
+
+// AppendFixed64Field appends the fixed64 field `num`. Zero values are omitted.
+func AppendFixed64Field(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	return binary.LittleEndian.AppendUint64(AppendTag(b, num, WireFixed64), v)
+}
+
+// AppendDoubleField appends the double field `num`. Zero values are omitted.
+func AppendDoubleField(b []byte, num int, v float64) []byte {
+	return AppendFixed64Field(b, num, math.Float64bits(v))
+}
+
+// AppendBytesField appends the bytes field `num`. Empty values are omitted.
+func AppendBytesField(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+
+	b = AppendVarint(AppendTag(b, num, WireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// AppendStringField appends the string field `num`. Empty values are omitted.
+func AppendStringField(b []byte, num int, v string) []byte {
+	if v == "" {
+		return b
+	}
+
+	b = AppendVarint(AppendTag(b, num, WireBytes), uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// AppendMessageField appends the embedded message field `num`, encoded by
+// `encode`. Unlike other fields, empty messages aren't omitted.
+func AppendMessageField(b []byte, num int, encode func(b []byte) []byte) []byte {
+	msg := encode(nil)
+
+	b = AppendVarint(AppendTag(b, num, WireBytes), uint64(len(msg)))
+
+	return append(b, msg...)
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package protobuf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []byte
+		want []byte
+	}{
+		{
+			name: "Should work - varint",
+			got:  AppendVarintField(nil, 1, 150),
+			want: []byte{0x08, 0x96, 0x01},
+		},
+		{
+			name: "Should work - varint, zero value omitted",
+			got:  AppendVarintField(nil, 1, 0),
+			want: nil,
+		},
+		{
+			name: "Should work - string",
+			got:  AppendStringField(nil, 2, "testing"),
+			want: []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'},
+		},
+		{
+			name: "Should work - fixed32",
+			got:  AppendFixed32Field(nil, 3, 1),
+			want: []byte{0x1d, 0x01, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "Should work - fixed64",
+			got:  AppendFixed64Field(nil, 1, 1),
+			want: []byte{0x09, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "Should work - message",
+			got: AppendMessageField(nil, 3, func(b []byte) []byte {
+				return AppendVarintField(b, 1, 150)
+			}),
+			want: []byte{0x1a, 0x03, 0x08, 0x96, 0x01},
+		},
+		{
+			name: "Should work - empty message",
+			got: AppendMessageField(nil, 3, func(b []byte) []byte {
+				return b
+			}),
+			want: []byte{0x1a, 0x00},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Equal(tt.got, tt.want) {
+				t.Errorf("Got %x, want %x", tt.got, tt.want)
+			}
+		})
+	}
+}
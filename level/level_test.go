@@ -6,6 +6,7 @@ package level
 
 import (
 	"errors"
+	"log/slog"
 	"testing"
 )
 
@@ -169,3 +170,76 @@ func TestLevelsToString(t *testing.T) {
 		})
 	}
 }
+
+func TestFromSlog(t *testing.T) {
+	tests := []struct {
+		name string
+		l    slog.Level
+		want Level
+	}{
+		{name: "Should work - trace", l: SlogTrace, want: Trace},
+		{name: "Should work - debug", l: slog.LevelDebug, want: Debug},
+		{name: "Should work - info", l: slog.LevelInfo, want: Info},
+		{name: "Should work - in between", l: slog.LevelInfo + 2, want: Info},
+		{name: "Should work - warn", l: slog.LevelWarn, want: Warn},
+		{name: "Should work - error", l: slog.LevelError, want: Error},
+		{name: "Should work - above error", l: SlogFatal, want: Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromSlog(tt.l); got != tt.want {
+				t.Errorf("FromSlog() = %v, want %v", got, tt.want)
+			}
+
+			if tt.want != Error && FromSlog(tt.want.Slog()) != tt.want {
+				t.Errorf("FromSlog(%v.Slog()) isn't %v", tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevel_SyslogSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		l    Level
+		want int
+	}{
+		{name: "Should work - fatal", l: Fatal, want: SyslogCritical},
+		{name: "Should work - error", l: Error, want: SyslogError},
+		{name: "Should work - warn", l: Warn, want: SyslogWarning},
+		{name: "Should work - info", l: Info, want: SyslogInformational},
+		{name: "Should work - debug", l: Debug, want: SyslogDebug},
+		{name: "Should work - trace", l: Trace, want: SyslogDebug},
+		{name: "Should work - none", l: None, want: SyslogInformational},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.l.SyslogSeverity(); got != tt.want {
+				t.Errorf("SyslogSeverity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevel_OTelSeverityNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		l    Level
+		want int
+	}{
+		{name: "Should work - fatal", l: Fatal, want: OTelSeverityFatal},
+		{name: "Should work - error", l: Error, want: OTelSeverityError},
+		{name: "Should work - warn", l: Warn, want: OTelSeverityWarn},
+		{name: "Should work - info", l: Info, want: OTelSeverityInfo},
+		{name: "Should work - debug", l: Debug, want: OTelSeverityDebug},
+		{name: "Should work - trace", l: Trace, want: OTelSeverityTrace},
+		{name: "Should work - none", l: None, want: OTelSeverityUnspecified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.l.OTelSeverityNumber(); got != tt.want {
+				t.Errorf("OTelSeverityNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
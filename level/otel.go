@@ -0,0 +1,39 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package level
+
+// OpenTelemetry severity numbers, the first of each range, as defined in the
+// OpenTelemetry logs data model.
+const (
+	OTelSeverityUnspecified = 0
+	OTelSeverityTrace       = 1
+	OTelSeverityDebug       = 5
+	OTelSeverityInfo        = 9
+	OTelSeverityWarn        = 13
+	OTelSeverityError       = 17
+	OTelSeverityFatal       = 21
+)
+
+// OTelSeverityNumber returns the OpenTelemetry severity number equivalent of
+// the level. `None`, and unknown levels are mapped to
+// `OTelSeverityUnspecified`.
+func (l Level) OTelSeverityNumber() int {
+	switch l {
+	case Fatal:
+		return OTelSeverityFatal
+	case Error:
+		return OTelSeverityError
+	case Warn:
+		return OTelSeverityWarn
+	case Info:
+		return OTelSeverityInfo
+	case Debug:
+		return OTelSeverityDebug
+	case Trace:
+		return OTelSeverityTrace
+	}
+
+	return OTelSeverityUnspecified
+}
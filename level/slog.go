@@ -0,0 +1,56 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package level
+
+import "log/slog"
+
+// SlogTrace is the `slog.Level` used to represent the `Trace` level. `slog`
+// doesn't define it, so it follows the same spacing used by `slog` levels.
+const SlogTrace = slog.LevelDebug - 4
+
+// SlogFatal is the `slog.Level` used to represent the `Fatal` level. `slog`
+// doesn't define it, so it follows the same spacing used by `slog` levels.
+const SlogFatal = slog.LevelError + 4
+
+// FromSlog returns a `Level` from a given `slog.Level`. Levels in between are
+// rounded to the next less severe `Level`, e.g.: `slog.LevelInfo+2` -> `Info`.
+//
+// Note: `slog` levels above `slog.LevelError` are mapped to `Error`, and not to
+// `Fatal` - which would exit the application.
+func FromSlog(l slog.Level) Level {
+	switch {
+	case l >= slog.LevelError:
+		return Error
+	case l >= slog.LevelWarn:
+		return Warn
+	case l >= slog.LevelInfo:
+		return Info
+	case l >= slog.LevelDebug:
+		return Debug
+	default:
+		return Trace
+	}
+}
+
+// Slog returns the `slog.Level` equivalent of the level. `None`, and unknown
+// levels are mapped to `slog.LevelInfo`.
+func (l Level) Slog() slog.Level {
+	switch l {
+	case Fatal:
+		return SlogFatal
+	case Error:
+		return slog.LevelError
+	case Warn:
+		return slog.LevelWarn
+	case Debug:
+		return slog.LevelDebug
+	case Trace:
+		return SlogTrace
+	case None, Info:
+		return slog.LevelInfo
+	}
+
+	return slog.LevelInfo
+}
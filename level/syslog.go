@@ -0,0 +1,37 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package level
+
+// Syslog severities, as defined in RFC 5424.
+const (
+	SyslogEmergency = iota
+	SyslogAlert
+	SyslogCritical
+	SyslogError
+	SyslogWarning
+	SyslogNotice
+	SyslogInformational
+	SyslogDebug
+)
+
+// SyslogSeverity returns the syslog severity (RFC 5424) equivalent of the
+// level. `Trace` is mapped to `SyslogDebug`, the least severe one. `None`, and
+// unknown levels are mapped to `SyslogInformational`.
+func (l Level) SyslogSeverity() int {
+	switch l {
+	case Fatal:
+		return SyslogCritical
+	case Error:
+		return SyslogError
+	case Warn:
+		return SyslogWarning
+	case Debug, Trace:
+		return SyslogDebug
+	case None, Info:
+		return SyslogInformational
+	}
+
+	return SyslogInformational
+}
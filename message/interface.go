@@ -5,6 +5,7 @@
 package message
 
 import (
+	"runtime"
 	"time"
 
 	"github.com/saucelabs/sypl/content"
@@ -32,6 +33,13 @@ type IMessage interface {
 	// String interface.
 	String() string
 
+	// GetCaller returns the caller - file, line, and function, which printed
+	// the message. If unknown, it's a zero-valued frame.
+	GetCaller() runtime.Frame
+
+	// SetCaller sets the caller.
+	SetCaller(caller runtime.Frame) IMessage
+
 	// GetComponentName returns the component name.
 	GetComponentName() string
 
@@ -107,6 +115,13 @@ type IMessage interface {
 	// SetProcessorsNames sets the processors names that should be used.
 	SetProcessorsNames(processorsNames []string) IMessage
 
+	// GetStackTrace returns the stack trace captured when the message was
+	// printed, if any.
+	GetStackTrace() []runtime.Frame
+
+	// SetStackTrace sets the stack trace.
+	SetStackTrace(stackTrace []runtime.Frame) IMessage
+
 	// GetTimestamp returns the timestamp.
 	GetTimestamp() time.Time
 
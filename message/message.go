@@ -5,6 +5,7 @@
 package message
 
 import (
+	"runtime"
 	"strings"
 	"time"
 
@@ -52,6 +53,9 @@ func newLineBreaker(knownLineBreakers ...string) *lineBreaker {
 type message struct {
 	*options.Options
 
+	// Caller which printed the message.
+	caller runtime.Frame
+
 	// Name of the component logging the message.
 	componentName string
 
@@ -64,6 +68,9 @@ type message struct {
 	// Debug capabilities.
 	debug *debug.Debug
 
+	// Stack trace captured when the message was printed.
+	stackTrace []runtime.Frame
+
 	// Content that should be written to `Output`.
 	Content content.IContent
 
@@ -167,6 +174,19 @@ func (m *message) Strip() {
 // IMessage interface implementation.
 //////
 
+// GetCaller returns the caller - file, line, and function, which printed the
+// message. If unknown, it's a zero-valued frame.
+func (m *message) GetCaller() runtime.Frame {
+	return m.caller
+}
+
+// SetCaller sets the caller.
+func (m *message) SetCaller(caller runtime.Frame) IMessage {
+	m.caller = caller
+
+	return m
+}
+
 // GetComponentName returns the component name.
 func (m *message) GetComponentName() string {
 	return m.componentName
@@ -302,6 +322,19 @@ func (m *message) SetProcessorsNames(processorsNames []string) IMessage {
 	return m
 }
 
+// GetStackTrace returns the stack trace captured when the message was printed,
+// if any.
+func (m *message) GetStackTrace() []runtime.Frame {
+	return m.stackTrace
+}
+
+// SetStackTrace sets the stack trace.
+func (m *message) SetStackTrace(stackTrace []runtime.Frame) IMessage {
+	m.stackTrace = stackTrace
+
+	return m
+}
+
 // GetTimestamp returns the timestamp.
 func (m *message) GetTimestamp() time.Time {
 	return m.Timestamp
@@ -335,6 +368,7 @@ func Copy(m IMessage) IMessage {
 	// Adds tags to `message.tags`.
 	msg.AddTags(m.GetTags()...)
 
+	msg.SetCaller(m.GetCaller())
 	msg.SetComponentName(m.GetComponentName())
 	msg.SetDebugEnvVarRegexes(m.GetDebugEnvVarRegexes())
 
@@ -349,6 +383,7 @@ func Copy(m IMessage) IMessage {
 	msg.SetOutputsNames(m.GetOutputsNames())
 	msg.SetProcessorName(m.GetProcessorName())
 	msg.SetProcessorsNames(m.GetProcessorsNames())
+	msg.SetStackTrace(m.GetStackTrace())
 	msg.SetTimestamp(m.GetTimestamp())
 
 	return msg
@@ -0,0 +1,368 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/saucelabs/sypl/formatter"
+	"github.com/saucelabs/sypl/internal/builtin"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/processor"
+)
+
+// Async defaults.
+const (
+	// DefaultAsyncLevel is the default level of the `OverflowDropBelowLevel`
+	// policy.
+	DefaultAsyncLevel = level.Error
+
+	// DefaultAsyncQueueSize is the default size of the `AsyncOutput` queue.
+	DefaultAsyncQueueSize = 1024
+)
+
+// OverflowPolicy defines what happens when the `AsyncOutput` queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the writer until there's room in the queue.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the message being written.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest queued message, making room for the
+	// one being written.
+	OverflowDropOldest
+
+	// OverflowDropBelowLevel drops the message being written if its level is
+	// below (less severe than) `AsyncOptions.Level`, otherwise blocks.
+	OverflowDropBelowLevel
+)
+
+var overflowPolicyNames = [...]string{"Block", "DropNewest", "DropOldest", "DropBelowLevel"}
+
+// String interface implementation.
+func (p OverflowPolicy) String() string {
+	if p < OverflowBlock || p > OverflowDropBelowLevel {
+		return "Unknown"
+	}
+
+	return overflowPolicyNames[p]
+}
+
+// AsyncOptions are options for the `AsyncOutput`.
+type AsyncOptions struct {
+	// Level used by the `OverflowDropBelowLevel` policy: messages below it
+	// are dropped, others block. Default (`None`) is `DefaultAsyncLevel`.
+	Level level.Level
+
+	// Policy applied when the queue is full. Default is `OverflowBlock`.
+	OverflowPolicy OverflowPolicy
+
+	// QueueSize is the max number of queued messages. Default is
+	// `DefaultAsyncQueueSize`.
+	QueueSize int
+}
+
+// AsyncOutput wraps an output, decoupling writing from the caller: messages
+// are queued in a bounded queue, and written by a background worker, in order.
+//
+// Notes:
+// - Messages are written by the wrapped output, so processors, formatter, and
+// levels are honored as usual - just later.
+// - Call `Flush` to wait for queued messages to be written, and `Close` to
+// stop the worker.
+// - Queued messages are written as is, don't reuse them after writing.
+type AsyncOutput struct {
+	IOutput
+
+	// Options.
+	options AsyncOptions
+
+	// Guards the queue, and its state.
+	mu sync.Mutex
+
+	// Signaled when a message is queued, or when closing.
+	notEmpty *sync.Cond
+
+	// Signaled when a message is dequeued, or when closing.
+	notFull *sync.Cond
+
+	// Ring buffer based queue.
+	queue []message.IMessage
+	head  int
+	size  int
+
+	// Closed when the queue is drained, and no message is being written.
+	idle chan struct{}
+
+	// Whether the output is closed.
+	closed bool
+
+	// Closed when the worker exits.
+	done chan struct{}
+
+	// Counters.
+	dropped  uint64
+	enqueued uint64
+	written  uint64
+}
+
+//////
+// IOutput interface implementation.
+//////
+
+// SetBuiltinLogger sets the Golang's builtin logger.
+func (a *AsyncOutput) SetBuiltinLogger(builtinLogger *builtin.Builtin) IOutput {
+	a.IOutput.SetBuiltinLogger(builtinLogger)
+
+	return a
+}
+
+// SetFormatter sets the formatter.
+func (a *AsyncOutput) SetFormatter(fmtr formatter.IFormatter) IOutput {
+	a.IOutput.SetFormatter(fmtr)
+
+	return a
+}
+
+// SetMaxLevel sets the max level.
+func (a *AsyncOutput) SetMaxLevel(l level.Level) IOutput {
+	a.IOutput.SetMaxLevel(l)
+
+	return a
+}
+
+// SetMinLevel sets the min level. `None` means no min level.
+func (a *AsyncOutput) SetMinLevel(l level.Level) IOutput {
+	a.IOutput.SetMinLevel(l)
+
+	return a
+}
+
+// SetLevelRange sets both, the min, and the max level. Only messages within
+// the range - inclusive, will be written.
+func (a *AsyncOutput) SetLevelRange(minLevel, maxLevel level.Level) IOutput {
+	a.IOutput.SetLevelRange(minLevel, maxLevel)
+
+	return a
+}
+
+// AddProcessors adds one or more processors.
+func (a *AsyncOutput) AddProcessors(processors ...processor.IProcessor) IOutput {
+	a.IOutput.AddProcessors(processors...)
+
+	return a
+}
+
+// SetProcessors sets one or more processors.
+func (a *AsyncOutput) SetProcessors(processors ...processor.IProcessor) IOutput {
+	a.IOutput.SetProcessors(processors...)
+
+	return a
+}
+
+// SetWriter sets the writer.
+func (a *AsyncOutput) SetWriter(w io.Writer) IOutput {
+	a.IOutput.SetWriter(w)
+
+	return a
+}
+
+// Write queues the message to be written by the wrapped output. If the queue
+// is full, the overflow policy is applied. Dropping a message isn't an error.
+// Writing to a closed output returns `ErrOutputClosed`.
+func (a *AsyncOutput) Write(m message.IMessage) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for !a.closed && a.size == len(a.queue) {
+		switch a.options.OverflowPolicy {
+		case OverflowDropNewest:
+			atomic.AddUint64(&a.dropped, 1)
+
+			return nil
+		case OverflowDropOldest:
+			a.queue[a.head] = nil
+			a.head = (a.head + 1) % len(a.queue)
+			a.size--
+
+			atomic.AddUint64(&a.dropped, 1)
+		case OverflowDropBelowLevel:
+			if m.GetLevel() > a.options.Level {
+				atomic.AddUint64(&a.dropped, 1)
+
+				return nil
+			}
+
+			a.notFull.Wait()
+		default:
+			a.notFull.Wait()
+		}
+	}
+
+	if a.closed {
+		return ErrOutputClosed
+	}
+
+	// Not idle anymore.
+	select {
+	case <-a.idle:
+		a.idle = make(chan struct{})
+	default:
+	}
+
+	a.queue[(a.head+a.size)%len(a.queue)] = m
+	a.size++
+
+	atomic.AddUint64(&a.enqueued, 1)
+
+	a.notEmpty.Signal()
+
+	return nil
+}
+
+//////
+// Async.
+//////
+
+// GetDropped returns the number of dropped messages.
+func (a *AsyncOutput) GetDropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// GetEnqueued returns the number of queued messages, since creation.
+func (a *AsyncOutput) GetEnqueued() uint64 {
+	return atomic.LoadUint64(&a.enqueued)
+}
+
+// GetWritten returns the number of messages handed to the wrapped output.
+func (a *AsyncOutput) GetWritten() uint64 {
+	return atomic.LoadUint64(&a.written)
+}
+
+// Flush blocks until all queued messages are written, then flushes the
+// wrapped output.
+func (a *AsyncOutput) Flush() error {
+	return a.FlushContext(context.Background())
+}
+
+// FlushContext blocks until all queued messages are written, then flushes the
+// wrapped output, or until the context is done.
+func (a *AsyncOutput) FlushContext(ctx context.Context) error {
+	a.mu.Lock()
+	idle := a.idle
+	a.mu.Unlock()
+
+	select {
+	case <-idle:
+		return a.IOutput.Flush()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting messages, writes the queued ones, stops the worker,
+// and closes the wrapped output. Writers blocked by the `OverflowBlock` policy
+// get `ErrOutputClosed`. It's safe to call it multiple times.
+func (a *AsyncOutput) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	a.notEmpty.Broadcast()
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+
+	<-a.done
+
+	return a.IOutput.Close()
+}
+
+//////
+// Helpers.
+//////
+
+// Background worker. Writes queued messages in order, until closed, and
+// drained.
+func (a *AsyncOutput) run() {
+	defer close(a.done)
+
+	for {
+		a.mu.Lock()
+
+		for a.size == 0 && !a.closed {
+			a.notEmpty.Wait()
+		}
+
+		if a.size == 0 {
+			a.mu.Unlock()
+
+			return
+		}
+
+		m := a.queue[a.head]
+		a.queue[a.head] = nil
+		a.head = (a.head + 1) % len(a.queue)
+		a.size--
+
+		a.notFull.Signal()
+		a.mu.Unlock()
+
+		// Errors are already reported by the wrapped output.
+		_ = a.IOutput.Write(m)
+
+		atomic.AddUint64(&a.written, 1)
+
+		a.mu.Lock()
+
+		if a.size == 0 {
+			close(a.idle)
+		}
+
+		a.mu.Unlock()
+	}
+}
+
+//////
+// Factory.
+//////
+
+// Async wraps `o`, making it asynchronous. `opts` is optional.
+func Async(o IOutput, opts *AsyncOptions) *AsyncOutput {
+	a := &AsyncOutput{
+		IOutput: o,
+
+		done: make(chan struct{}),
+		idle: make(chan struct{}),
+	}
+
+	if opts != nil {
+		a.options = *opts
+	}
+
+	// Otherwise, all messages would be dropped.
+	if a.options.OverflowPolicy == OverflowDropBelowLevel && a.options.Level == level.None {
+		a.options.Level = DefaultAsyncLevel
+	}
+
+	if a.options.QueueSize <= 0 {
+		a.options.QueueSize = DefaultAsyncQueueSize
+	}
+
+	a.queue = make([]message.IMessage, a.options.QueueSize)
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+
+	// Nothing queued yet.
+	close(a.idle)
+
+	go a.run()
+
+	return a
+}
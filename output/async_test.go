@@ -0,0 +1,234 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Message-aware writer which records written contents. It blocks until
+// released, allowing to fill the queue.
+type gatedWriter struct {
+	mu       sync.Mutex
+	gate     chan struct{}
+	picked   chan struct{}
+	contents []string
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *gatedWriter) WriteMessage(m message.IMessage) error {
+	// Notifies, without blocking, the worker picked a message.
+	select {
+	case w.picked <- struct{}{}:
+	default:
+	}
+
+	<-w.gate
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.contents = append(w.contents, m.GetContent().GetProcessed())
+
+	return nil
+}
+
+func (w *gatedWriter) Contents() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string{}, w.contents...)
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{}), picked: make(chan struct{}, 1)}
+}
+
+func TestAsync(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *AsyncOptions
+		messages     []message.IMessage
+		want         []string
+		wantDropped  uint64
+		wantEnqueued uint64
+	}{
+		{
+			name: "Should work",
+			opts: nil,
+			messages: []message.IMessage{
+				message.New(level.Info, "1"),
+				message.New(level.Info, "2"),
+				message.New(level.Info, "3"),
+			},
+			want:         []string{"1", "2", "3"},
+			wantDropped:  0,
+			wantEnqueued: 3,
+		},
+		{
+			name: "Should work - drop newest",
+			opts: &AsyncOptions{OverflowPolicy: OverflowDropNewest, QueueSize: 2},
+			messages: []message.IMessage{
+				message.New(level.Info, "1"),
+				message.New(level.Info, "2"),
+				message.New(level.Info, "3"),
+				message.New(level.Info, "4"),
+			},
+			want:         []string{"1", "2", "3"},
+			wantDropped:  1,
+			wantEnqueued: 3,
+		},
+		{
+			name: "Should work - drop oldest",
+			opts: &AsyncOptions{OverflowPolicy: OverflowDropOldest, QueueSize: 2},
+			messages: []message.IMessage{
+				message.New(level.Info, "1"),
+				message.New(level.Info, "2"),
+				message.New(level.Info, "3"),
+				message.New(level.Info, "4"),
+			},
+			want:         []string{"1", "3", "4"},
+			wantDropped:  1,
+			wantEnqueued: 4,
+		},
+		{
+			name: "Should work - drop below level",
+			opts: &AsyncOptions{OverflowPolicy: OverflowDropBelowLevel, Level: level.Warn, QueueSize: 2},
+			messages: []message.IMessage{
+				message.New(level.Info, "1"),
+				message.New(level.Info, "2"),
+				message.New(level.Info, "3"),
+				message.New(level.Debug, "4"),
+			},
+			want:         []string{"1", "2", "3"},
+			wantDropped:  1,
+			wantEnqueued: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newGatedWriter()
+
+			a := Async(New("Gated", level.Trace, w), tt.opts)
+
+			// First message is picked by the worker, which blocks on the
+			// gate, so the queue can be filled.
+			if err := a.Write(tt.messages[0]); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			<-w.picked
+
+			for _, m := range tt.messages[1:] {
+				if err := a.Write(m); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			close(w.gate)
+
+			if err := a.Flush(); err != nil {
+				t.Fatalf("Flush failed: %s", err)
+			}
+
+			if got := w.Contents(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %v, want %v", got, tt.want)
+			}
+
+			if a.GetDropped() != tt.wantDropped {
+				t.Errorf("Got %d dropped, want %d", a.GetDropped(), tt.wantDropped)
+			}
+
+			if a.GetEnqueued() != tt.wantEnqueued {
+				t.Errorf("Got %d enqueued, want %d", a.GetEnqueued(), tt.wantEnqueued)
+			}
+
+			if err := a.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			if err := a.Write(message.New(level.Info, "closed")); !errors.Is(err, ErrOutputClosed) {
+				t.Errorf("Got %v, want %v", err, ErrOutputClosed)
+			}
+		})
+	}
+}
+
+func TestAsync_DropBelowLevelDefault(t *testing.T) {
+	w := newGatedWriter()
+
+	a := Async(New("Gated", level.Trace, w), &AsyncOptions{OverflowPolicy: OverflowDropBelowLevel, QueueSize: 1})
+
+	if err := a.Write(message.New(level.Info, "1")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	<-w.picked
+
+	if err := a.Write(message.New(level.Info, "2")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	// Queue is full. Should drop below the default level, and block otherwise.
+	if err := a.Write(message.New(level.Warn, "3")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, func() { close(w.gate) })
+
+	if err := a.Write(message.New(level.Error, "4")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if got := w.Contents(); !reflect.DeepEqual(got, []string{"1", "2", "4"}) {
+		t.Errorf("Got %v, want %v", got, []string{"1", "2", "4"})
+	}
+
+	if a.GetDropped() != 1 {
+		t.Errorf("Got %d dropped, want 1", a.GetDropped())
+	}
+}
+
+func TestAsync_FlushContext(t *testing.T) {
+	w := newGatedWriter()
+
+	a := Async(New("Gated", level.Trace, w), nil)
+
+	if err := a.Write(message.New(level.Info, "1")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := a.FlushContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(w.gate)
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if got := w.Contents(); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("Got %v, want %v", got, []string{"1"})
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"sync"
+	"time"
+)
+
+// Batch defaults.
+const (
+	DefaultBatchInterval = time.Second
+	DefaultBatchMaxBytes = 1 << 20
+	DefaultBatchMaxCount = 100
+)
+
+// Max number of batches waiting to be sent. Once full, writers block.
+const batcherQueueSize = 4
+
+// BatchOptions are options for batching outputs. A batch is sent when any of
+// the limits is reached. Zero values fallback to the defaults.
+type BatchOptions struct {
+	// Interval is the max time a message waits in a batch before it's sent.
+	// Default is `DefaultBatchInterval`.
+	Interval time.Duration
+
+	// MaxBytes is the max size of a batch. Default is `DefaultBatchMaxBytes`.
+	MaxBytes int
+
+	// MaxCount is the max number of messages in a batch. Default is
+	// `DefaultBatchMaxCount`.
+	MaxCount int
+}
+
+// Returns options with defaults applied.
+func (opts BatchOptions) withDefaults() BatchOptions {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultBatchInterval
+	}
+
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBatchMaxBytes
+	}
+
+	if opts.MaxCount <= 0 {
+		opts.MaxCount = DefaultBatchMaxCount
+	}
+
+	return opts
+}
+
+// A batch to be sent. If set, `flushed` is closed once sent.
+type batchRequest[T any] struct {
+	items   []T
+	flushed chan struct{}
+}
+
+// Groups items into batches by count, size, and time. Batches are sent, in
+// order, by a background worker, so slow sends don't block writers, unless
+// too many batches are waiting.
+type batcher[T any] struct {
+	// Options.
+	options BatchOptions
+
+	// Sends a batch. Errors are handled by the sender.
+	send func(items []T)
+
+	// Guards the current batch, and its state.
+	mu sync.Mutex
+
+	// Current batch.
+	items []T
+	size  int
+
+	// Whether the batcher is closed.
+	closed bool
+
+	// Closed when closing, before the last batch is sent. Senders should stop
+	// waiting, e.g.: to retry.
+	closing chan struct{}
+
+	// Batches waiting to be sent. Only written with the lock held, so order is
+	// preserved.
+	requests chan batchRequest[T]
+
+	// Closed when the worker exits.
+	done chan struct{}
+}
+
+// Adds an item of the specified size to the current batch. Returns
+// `ErrOutputClosed` if closed.
+func (b *batcher[T]) add(item T, size int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrOutputClosed
+	}
+
+	// Item doesn't fit in the current batch.
+	if len(b.items) > 0 && b.size+size > b.options.MaxBytes {
+		b.enqueue(nil)
+	}
+
+	b.items = append(b.items, item)
+	b.size += size
+
+	if len(b.items) >= b.options.MaxCount || b.size >= b.options.MaxBytes {
+		b.enqueue(nil)
+	}
+
+	return nil
+}
+
+// Sends the current batch, and blocks until all batches are sent.
+func (b *batcher[T]) flush() {
+	flushed := make(chan struct{})
+
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+
+		return
+	}
+
+	b.enqueue(flushed)
+
+	b.mu.Unlock()
+
+	<-flushed
+}
+
+// Sends the current batch, and blocks until all batches are sent, then stops
+// the worker. It's safe to call it multiple times.
+func (b *batcher[T]) close() {
+	b.mu.Lock()
+
+	if !b.closed {
+		b.closed = true
+
+		close(b.closing)
+
+		b.enqueue(nil)
+
+		close(b.requests)
+	}
+
+	b.mu.Unlock()
+
+	<-b.done
+}
+
+// Queues the current batch to be sent, and starts a new one.
+//
+// Note: Must be called with the lock held.
+func (b *batcher[T]) enqueue(flushed chan struct{}) {
+	if len(b.items) == 0 && flushed == nil {
+		return
+	}
+
+	b.requests <- batchRequest[T]{items: b.items, flushed: flushed}
+
+	b.items = nil
+	b.size = 0
+}
+
+// Background worker. Sends batches in order, until closed.
+func (b *batcher[T]) run() {
+	defer close(b.done)
+
+	for req := range b.requests {
+		if len(req.items) > 0 {
+			b.send(req.items)
+		}
+
+		if req.flushed != nil {
+			close(req.flushed)
+		}
+	}
+}
+
+// Sends the current batch on every interval, until the worker exits.
+func (b *batcher[T]) tick() {
+	ticker := time.NewTicker(b.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+
+			if !b.closed {
+				b.enqueue(nil)
+			}
+
+			b.mu.Unlock()
+		}
+	}
+}
+
+// Creates a batcher, and starts it. `send` is called by the worker.
+func newBatcher[T any](opts BatchOptions, send func(items []T)) *batcher[T] {
+	b := &batcher[T]{
+		options: opts.withDefaults(),
+		send:    send,
+
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+		requests: make(chan batchRequest[T], batcherQueueSize),
+	}
+
+	go b.run()
+	go b.tick()
+
+	return b
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  BatchOptions
+		items []string
+		want  [][]string
+	}{
+		{
+			name:  "Should work - max count",
+			opts:  BatchOptions{Interval: time.Hour, MaxCount: 2},
+			items: []string{"1", "2", "3", "4", "5"},
+			want:  [][]string{{"1", "2"}, {"3", "4"}, {"5"}},
+		},
+		{
+			name:  "Should work - max bytes",
+			opts:  BatchOptions{Interval: time.Hour, MaxBytes: 5},
+			items: []string{"12", "34", "56", "7890", "12345"},
+			want:  [][]string{{"12", "34"}, {"56"}, {"7890"}, {"12345"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+
+			b := newBatcher(tt.opts, func(items []string) {
+				got = append(got, items)
+			})
+
+			for _, item := range tt.items {
+				if err := b.add(item, len(item)); err != nil {
+					t.Fatalf("add failed: %s", err)
+				}
+			}
+
+			b.close()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %v, want %v", got, tt.want)
+			}
+
+			if err := b.add("closed", 1); !errors.Is(err, ErrOutputClosed) {
+				t.Errorf("Got %v, want %v", err, ErrOutputClosed)
+			}
+		})
+	}
+}
+
+func TestBatcher_Interval(t *testing.T) {
+	sent := make(chan []string, 1)
+
+	b := newBatcher(BatchOptions{Interval: 10 * time.Millisecond}, func(items []string) {
+		sent <- items
+	})
+	defer b.close()
+
+	if err := b.add("1", 1); err != nil {
+		t.Fatalf("add failed: %s", err)
+	}
+
+	select {
+	case got := <-sent:
+		if !reflect.DeepEqual(got, []string{"1"}) {
+			t.Errorf("Got %v, want %v", got, []string{"1"})
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the batch")
+	}
+}
+
+func TestBatcher_Flush(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+
+	b := newBatcher(BatchOptions{Interval: time.Hour}, func(items []string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		got = append(got, items...)
+	})
+	defer b.close()
+
+	for _, item := range []string{"1", "2"} {
+		if err := b.add(item, len(item)); err != nil {
+			t.Fatalf("add failed: %s", err)
+		}
+	}
+
+	b.flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("Got %v, want %v", got, []string{"1", "2"})
+	}
+}
@@ -7,9 +7,11 @@ package output
 import (
 	"io"
 	"log"
+	"log/slog"
 	"os"
 
 	"github.com/saucelabs/lumberjack/v3"
+	"github.com/saucelabs/sypl/formatter"
 	"github.com/saucelabs/sypl/level"
 	"github.com/saucelabs/sypl/processor"
 	"github.com/saucelabs/sypl/safebuffer"
@@ -112,3 +114,89 @@ func SafeBuffer(maxLevel level.Level, processors ...processor.IProcessor) (*safe
 
 	return &buf, o
 }
+
+// Recorder is a built-in `output` - named `Recorder`, that keeps the most
+// recent messages in memory (flight recorder). Use the returned writer to
+// query, and dump them. See `RecorderOptions`, which is optional.
+func Recorder(maxLevel level.Level, opts *RecorderOptions, processors ...processor.IProcessor) (*RecorderWriter, IOutput) {
+	r := NewRecorderWriter(opts)
+
+	return r, New("Recorder", maxLevel, r, processors...)
+}
+
+// Slog is a built-in `output` - named `Slog`, that writes to the specified
+// `slog.Logger`. It allows messages to land in the same destination as
+// libraries logging via `slog`. If `logger` is nil, `slog.Default()` is used.
+//
+// Note: Don't use it with a `slog.Logger` backed by sypl's `SlogHandler`
+// pointing to the same logger, otherwise messages will loop.
+func Slog(logger *slog.Logger, maxLevel level.Level, processors ...processor.IProcessor) IOutput {
+	return New("Slog", maxLevel, NewSlogWriter(logger), processors...)
+}
+
+// Syslog is a built-in `output` - named `Syslog`, that writes to a syslog
+// daemon, local by default. See `SyslogOptions`, which is optional.
+func Syslog(maxLevel level.Level, opts *SyslogOptions, processors ...processor.IProcessor) IOutput {
+	return New("Syslog", maxLevel, NewSyslogWriter(opts), processors...)
+}
+
+// Journald is a built-in `output` - named `Journald`, that writes to journald
+// using its native protocol. See `JournaldOptions`, which is optional.
+//
+// Note: Only supported on Linux.
+func Journald(maxLevel level.Level, opts *JournaldOptions, processors ...processor.IProcessor) IOutput {
+	return New("Journald", maxLevel, NewJournaldWriter(opts), processors...)
+}
+
+// Network is a built-in `output` - named `Network`, that writes to a network
+// connection, e.g.: `tcp`, or `udp`. It connects lazily, reconnects with
+// exponential backoff, and buffers messages while disconnected. See
+// `NetworkOptions`, which is optional. The writer is returned, allowing health
+// checks via `State`.
+func Network(
+	network, address string,
+	maxLevel level.Level,
+	opts *NetworkOptions,
+	processors ...processor.IProcessor,
+) (*NetworkWriter, IOutput) {
+	w := NewNetworkWriter(network, address, opts)
+
+	return w, New("Network", maxLevel, w, processors...)
+}
+
+// HTTP is a built-in `output` - named `HTTP`, that POSTs messages, in
+// batches, to the specified URL, as NDJSON. Its formatter is `CompactJSON`.
+// See `HTTPOptions`, which is optional.
+func HTTP(url string, maxLevel level.Level, opts *HTTPOptions, processors ...processor.IProcessor) IOutput {
+	return New("HTTP", maxLevel, NewHTTPWriter(url, opts), processors...).
+		SetFormatter(formatter.CompactJSON(nil))
+}
+
+// Loki is a built-in `output` - named `Loki`, that pushes messages, in
+// batches, to Grafana Loki. `url` is the Loki's base URL, e.g.:
+// `http://localhost:3100`. See `LokiOptions`, which is optional.
+func Loki(url string, maxLevel level.Level, opts *LokiOptions, processors ...processor.IProcessor) IOutput {
+	return New("Loki", maxLevel, NewLokiWriter(url, opts), processors...)
+}
+
+// OTLP is a built-in `output` - named `OTLP`, that exports messages, in
+// batches, as OpenTelemetry logs via OTLP/HTTP. `url` is the collector's base
+// URL, e.g.: `http://localhost:4318`. See `OTLPOptions`, which is optional.
+func OTLP(url string, maxLevel level.Level, opts *OTLPOptions, processors ...processor.IProcessor) IOutput {
+	return New("OTLP", maxLevel, NewOTLPWriter(url, opts), processors...)
+}
+
+// GELF is a built-in `output` - named `GELF`, that sends messages to Graylog
+// over UDP, or TCP. Its formatter is `GELF`. See `GELFOptions`, which is
+// optional.
+func GELF(network, address string, maxLevel level.Level, opts *GELFOptions, processors ...processor.IProcessor) IOutput {
+	return New("GELF", maxLevel, NewGELFWriter(network, address, opts), processors...).
+		SetFormatter(formatter.GELF(nil))
+}
+
+// Fluentd is a built-in `output` - named `Fluentd`, that sends messages to
+// Fluentd, or compatible agents, using the Forward protocol. See
+// `FluentdOptions`, which is optional.
+func Fluentd(network, address string, maxLevel level.Level, opts *FluentdOptions, processors ...processor.IProcessor) IOutput {
+	return New("Fluentd", maxLevel, NewFluentdWriter(network, address, opts), processors...)
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import "errors"
+
+var (
+	// ErrFluentdAck is returned when Fluentd doesn't acknowledge a chunk as
+	// expected.
+	ErrFluentdAck = errors.New("unexpected Fluentd ack")
+
+	// ErrGELFTooLarge is returned when a GELF message needs more chunks than
+	// allowed.
+	ErrGELFTooLarge = errors.New("GELF message too large")
+
+	// ErrHTTPStatus is returned when an HTTP endpoint responds with a
+	// non-2xx status.
+	ErrHTTPStatus = errors.New("unexpected HTTP status")
+
+	// ErrJournaldUnsupported is returned when writing to journald on a
+	// non-Linux platform.
+	ErrJournaldUnsupported = errors.New("journald is only supported on Linux")
+
+	// ErrOutputClosed is returned when writing to a closed output.
+	ErrOutputClosed = errors.New("output is closed")
+)
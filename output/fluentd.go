@@ -0,0 +1,479 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saucelabs/sypl/internal/msgpack"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// Fluentd defaults.
+const (
+	DefaultFluentdMaxBackoff = 5 * time.Second
+	DefaultFluentdMaxRetries = 3
+	DefaultFluentdMinBackoff = 100 * time.Millisecond
+	DefaultFluentdTag        = "sypl"
+	DefaultFluentdTimeout    = 5 * time.Second
+)
+
+// FluentdMode is the Forward protocol mode.
+type FluentdMode int
+
+const (
+	// FluentdForward sends batches of entries, per tag:
+	// `[tag, [[time, record], ...], option]`.
+	FluentdForward FluentdMode = iota
+
+	// FluentdPackedForward sends batches of entries, per tag, as a binary
+	// stream of MessagePack entries: `[tag, bin, option]`.
+	FluentdPackedForward
+
+	// FluentdMessage sends one entry at time: `[tag, time, record, option]`.
+	FluentdMessage
+)
+
+// FluentdTagPart is a part of the tag.
+type FluentdTagPart int
+
+const (
+	// FluentdTagComponent is the component name.
+	FluentdTagComponent FluentdTagPart = iota
+
+	// FluentdTagOutput is the output name.
+	FluentdTagOutput
+
+	// FluentdTagTags are the message tags.
+	FluentdTagTags
+)
+
+// FluentdTag returns a tag function which joins the prefix, and the specified
+// parts with `.`, e.g.: `FluentdTag("app", FluentdTagComponent)` results in
+// `app.svc` for messages printed by the `svc` logger. Empty parts are
+// skipped. If the tag is empty, `DefaultFluentdTag` is used.
+func FluentdTag(prefix string, parts ...FluentdTagPart) func(m message.IMessage) string {
+	return func(m message.IMessage) string {
+		tag := []string{}
+
+		if prefix != "" {
+			tag = append(tag, prefix)
+		}
+
+		for _, part := range parts {
+			switch part {
+			case FluentdTagComponent:
+				tag = append(tag, m.GetComponentName())
+			case FluentdTagOutput:
+				tag = append(tag, strings.ToLower(m.GetOutputName()))
+			case FluentdTagTags:
+				tag = append(tag, m.GetTags()...)
+			}
+		}
+
+		nonEmpty := tag[:0]
+
+		for _, t := range tag {
+			if t != "" {
+				nonEmpty = append(nonEmpty, t)
+			}
+		}
+
+		if len(nonEmpty) == 0 {
+			return DefaultFluentdTag
+		}
+
+		return strings.Join(nonEmpty, ".")
+	}
+}
+
+// FluentdOptions are options for the `FluentdWriter`. Zero values fallback to
+// the defaults.
+type FluentdOptions struct {
+	// Batch options. Ignored by the `FluentdMessage` mode.
+	Batch BatchOptions
+
+	// MaxBackoff is the max interval between retries. Default is
+	// `DefaultFluentdMaxBackoff`.
+	MaxBackoff time.Duration
+
+	// MaxRetries is the max number of retries of a chunk. Default is
+	// `DefaultFluentdMaxRetries`. Negative disables retrying.
+	MaxRetries int
+
+	// MinBackoff is the initial interval between retries, it doubles on each
+	// retry. Default is `DefaultFluentdMinBackoff`.
+	MinBackoff time.Duration
+
+	// Mode. Default is `FluentdForward`.
+	Mode FluentdMode
+
+	// RequireAck, if true, each chunk has an ID which must be acknowledged by
+	// the server, otherwise it's retried.
+	RequireAck bool
+
+	// Tag returns the tag of the message. Default is
+	// `FluentdTag("", FluentdTagComponent)`.
+	Tag func(m message.IMessage) string
+
+	// Timeout for connecting, writing, and waiting for acks. Default is
+	// `DefaultFluentdTimeout`.
+	Timeout time.Duration
+}
+
+// A batched entry.
+type fluentdEntry struct {
+	tag       string
+	timestamp time.Time
+
+	// MessagePack encoded record.
+	record []byte
+}
+
+// FluentdWriter sends messages to Fluentd, or compatible agents, e.g.:
+// fluent-bit, using the Forward protocol. It's a message-aware writer.
+//
+// Notes:
+// - Records have the message, level, component name, output name, caller, if
+// known, and fields. Time is sent as `EventTime`, with nanoseconds.
+// - Messages are batched (see `BatchOptions`), and grouped by tag, except in
+// the `FluentdMessage` mode.
+// - It connects lazily, and reconnects on failures. Failed, or
+// unacknowledged chunks are retried with exponential backoff.
+type FluentdWriter struct {
+	// Network, and address to connect to.
+	network string
+	address string
+
+	// Options.
+	options FluentdOptions
+
+	// Batches entries.
+	batcher *batcher[fluentdEntry]
+
+	// Guards the connection.
+	mu sync.Mutex
+
+	// Current connection, if any.
+	conn net.Conn
+
+	// Decodes acks from the current connection.
+	decoder *msgpack.Decoder
+
+	// Counters.
+	failed uint64
+	sent   uint64
+}
+
+// Write implements the io.Writer interface. Content is sent at the `Info`
+// level.
+func (f *FluentdWriter) Write(p []byte) (int, error) {
+	if err := f.WriteMessage(message.New(level.Info, string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (f *FluentdWriter) WriteMessage(m message.IMessage) error {
+	entry := fluentdEntry{
+		tag:       f.options.Tag(m),
+		timestamp: m.GetTimestamp(),
+		record:    fluentdRecord(m),
+	}
+
+	return f.batcher.add(entry, len(entry.record))
+}
+
+// Flush sends pending messages, and blocks until they are sent, or failed.
+func (f *FluentdWriter) Flush() error {
+	f.batcher.flush()
+
+	return nil
+}
+
+// Close sends pending messages, stops, and closes the connection. It's safe to
+// call it multiple times.
+func (f *FluentdWriter) Close() error {
+	f.batcher.close()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.disconnect()
+}
+
+// GetFailed returns the number of messages which failed to be sent.
+func (f *FluentdWriter) GetFailed() uint64 {
+	return atomic.LoadUint64(&f.failed)
+}
+
+// GetSent returns the number of sent messages.
+func (f *FluentdWriter) GetSent() uint64 {
+	return atomic.LoadUint64(&f.sent)
+}
+
+//////
+// Helpers.
+//////
+
+// Sends the batch, per tag, according with the mode.
+func (f *FluentdWriter) send(entries []fluentdEntry) {
+	if f.options.Mode == FluentdMessage {
+		for _, entry := range entries {
+			f.sendChunk(entry.tag, []fluentdEntry{entry})
+		}
+
+		return
+	}
+
+	tags := []string{}
+	groups := map[string][]fluentdEntry{}
+
+	for _, entry := range entries {
+		if _, ok := groups[entry.tag]; !ok {
+			tags = append(tags, entry.tag)
+		}
+
+		groups[entry.tag] = append(groups[entry.tag], entry)
+	}
+
+	for _, tag := range tags {
+		f.sendChunk(tag, groups[tag])
+	}
+}
+
+// Encodes, and sends entries sharing the tag as a chunk, retrying on failure.
+func (f *FluentdWriter) sendChunk(tag string, entries []fluentdEntry) {
+	chunkID := ""
+
+	if f.options.RequireAck {
+		id := make([]byte, 16)
+
+		// Random chunk ID isn't critical, it only needs to be unique enough.
+		_, _ = rand.Read(id)
+
+		chunkID = base64.StdEncoding.EncodeToString(id)
+	}
+
+	payload := f.encode(tag, entries, chunkID)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	backoff := f.options.MinBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := f.sendPayload(payload, chunkID)
+		if err == nil {
+			atomic.AddUint64(&f.sent, uint64(len(entries)))
+
+			return
+		}
+
+		// Connection state is unknown.
+		_ = f.disconnect()
+
+		if attempt >= f.options.MaxRetries {
+			atomic.AddUint64(&f.failed, uint64(len(entries)))
+
+			log.Println(shared.ErrorPrefix, fmt.Sprintf("Fluentd Output: Failed to send %d messages:", len(entries)), err)
+
+			return
+		}
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+
+		if backoff > f.options.MaxBackoff {
+			backoff = f.options.MaxBackoff
+		}
+	}
+}
+
+// Sends the payload, connecting if needed, and waits for the ack, if
+// required.
+//
+// Note: Must be called with the lock held.
+func (f *FluentdWriter) sendPayload(payload []byte, chunkID string) error {
+	if f.conn == nil {
+		conn, err := net.DialTimeout(f.network, f.address, f.options.Timeout)
+		if err != nil {
+			return err
+		}
+
+		f.conn = conn
+		f.decoder = msgpack.NewDecoder(conn)
+	}
+
+	_ = f.conn.SetDeadline(time.Now().Add(f.options.Timeout))
+
+	if _, err := f.conn.Write(payload); err != nil {
+		return err
+	}
+
+	if chunkID == "" {
+		return nil
+	}
+
+	resp, err := f.decoder.Decode()
+	if err != nil {
+		return err
+	}
+
+	if ack, ok := resp.(map[string]interface{}); !ok || ack["ack"] != chunkID {
+		return fmt.Errorf("%w: %v", ErrFluentdAck, resp)
+	}
+
+	return nil
+}
+
+// Closes the connection, if any.
+//
+// Note: Must be called with the lock held.
+func (f *FluentdWriter) disconnect() error {
+	if f.conn == nil {
+		return nil
+	}
+
+	err := f.conn.Close()
+
+	f.conn = nil
+	f.decoder = nil
+
+	return err
+}
+
+// Encodes entries as a chunk, according with the mode.
+func (f *FluentdWriter) encode(tag string, entries []fluentdEntry, chunkID string) []byte {
+	var b []byte
+
+	switch f.options.Mode {
+	case FluentdMessage:
+		b = msgpack.AppendArrayHeader(b, 4)
+		b = msgpack.AppendString(b, tag)
+		b = msgpack.AppendEventTime(b, entries[0].timestamp)
+		b = append(b, entries[0].record...)
+	case FluentdPackedForward:
+		var packed []byte
+
+		for _, entry := range entries {
+			packed = appendFluentdEntry(packed, entry)
+		}
+
+		b = msgpack.AppendArrayHeader(b, 3)
+		b = msgpack.AppendString(b, tag)
+		b = msgpack.AppendBinary(b, packed)
+	default:
+		b = msgpack.AppendArrayHeader(b, 3)
+		b = msgpack.AppendString(b, tag)
+		b = msgpack.AppendArrayHeader(b, len(entries))
+
+		for _, entry := range entries {
+			b = appendFluentdEntry(b, entry)
+		}
+	}
+
+	option := map[string]interface{}{}
+
+	if f.options.Mode != FluentdMessage {
+		option["size"] = len(entries)
+	}
+
+	if chunkID != "" {
+		option["chunk"] = chunkID
+	}
+
+	return msgpack.AppendValue(b, option)
+}
+
+// Appends the entry as `[time, record]`.
+func appendFluentdEntry(b []byte, entry fluentdEntry) []byte {
+	b = msgpack.AppendArrayHeader(b, 2)
+	b = msgpack.AppendEventTime(b, entry.timestamp)
+
+	return append(b, entry.record...)
+}
+
+// Encodes the message as a record. Fields can't override core keys.
+func fluentdRecord(m message.IMessage) []byte {
+	record := map[string]interface{}{}
+
+	for k, v := range m.GetFields() {
+		record[k] = v
+	}
+
+	record["component"] = m.GetComponentName()
+	record["output"] = m.GetOutputName()
+	record["level"] = strings.ToLower(m.GetLevel().String())
+	record["message"] = strings.TrimRight(m.GetContent().GetProcessed(), "\r\n")
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		record["caller"] = shared.ShortCaller(caller.File, caller.Line)
+	}
+
+	return msgpack.AppendValue(nil, record)
+}
+
+//////
+// Factory.
+//////
+
+// NewFluentdWriter is the `FluentdWriter` factory. `network` is either `tcp`,
+// or `unix`. `opts` is optional.
+func NewFluentdWriter(network, address string, opts *FluentdOptions) *FluentdWriter {
+	f := &FluentdWriter{
+		network: network,
+		address: address,
+	}
+
+	if opts != nil {
+		f.options = *opts
+	}
+
+	if f.options.MaxBackoff <= 0 {
+		f.options.MaxBackoff = DefaultFluentdMaxBackoff
+	}
+
+	if f.options.MaxRetries == 0 {
+		f.options.MaxRetries = DefaultFluentdMaxRetries
+	}
+
+	if f.options.MinBackoff <= 0 {
+		f.options.MinBackoff = DefaultFluentdMinBackoff
+	}
+
+	if f.options.Tag == nil {
+		f.options.Tag = FluentdTag("", FluentdTagComponent)
+	}
+
+	if f.options.Timeout <= 0 {
+		f.options.Timeout = DefaultFluentdTimeout
+	}
+
+	batch := f.options.Batch
+
+	// Messages are sent one at time.
+	if f.options.Mode == FluentdMessage {
+		batch.MaxCount = 1
+	}
+
+	f.batcher = newBatcher(batch, f.send)
+
+	return f
+}
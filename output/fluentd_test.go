@@ -0,0 +1,324 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/internal/msgpack"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Fluentd which records received chunks, and acks them. The first `drop`
+// chunks aren't acked, and their connections are closed.
+type fakeFluentd struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	chunks [][]interface{}
+	drop   int
+}
+
+func (f *fakeFluentd) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeFluentd) handle(conn net.Conn) {
+	defer conn.Close()
+
+	d := msgpack.NewDecoder(conn)
+
+	for {
+		v, err := d.Decode()
+		if err != nil {
+			return
+		}
+
+		chunk, _ := v.([]interface{})
+
+		f.mu.Lock()
+
+		f.chunks = append(f.chunks, chunk)
+
+		drop := f.drop > 0
+
+		if drop {
+			f.drop--
+		}
+
+		f.mu.Unlock()
+
+		if drop {
+			return
+		}
+
+		option, _ := chunk[len(chunk)-1].(map[string]interface{})
+
+		if id, ok := option["chunk"]; ok {
+			if _, err := conn.Write(msgpack.AppendValue(nil, map[string]interface{}{"ack": id})); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Waits until `n` chunks are received, or times out.
+func (f *fakeFluentd) Chunks(n int) [][]interface{} {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		f.mu.Lock()
+		chunks := append([][]interface{}{}, f.chunks...)
+		f.mu.Unlock()
+
+		if len(chunks) >= n || time.Now().After(deadline) {
+			return chunks
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newFakeFluentd(t *testing.T, drop int) *fakeFluentd {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	f := &fakeFluentd{listener: l, drop: drop}
+
+	t.Cleanup(func() { l.Close() })
+
+	go f.serve()
+
+	return f
+}
+
+// Decodes `[time, record]` entries, returning messages.
+func fluentdEntriesMessages(t *testing.T, entries []interface{}) []string {
+	t.Helper()
+
+	messages := []string{}
+
+	for _, e := range entries {
+		entry, _ := e.([]interface{})
+
+		if ext, ok := entry[0].(msgpack.Ext); !ok || ext.Type != msgpack.EventTimeExtType || len(ext.Data) != 8 {
+			t.Errorf("Got %#v, want EventTime", entry[0])
+		}
+
+		record, _ := entry[1].(map[string]interface{})
+
+		messages = append(messages, record["message"].(string))
+	}
+
+	return messages
+}
+
+// Returns tags, and messages of chunks.
+func fluentdChunks(t *testing.T, mode FluentdMode, chunks [][]interface{}) ([]string, [][]string) {
+	t.Helper()
+
+	tags := []string{}
+	messages := [][]string{}
+
+	for _, chunk := range chunks {
+		tags = append(tags, chunk[0].(string))
+
+		switch mode {
+		case FluentdMessage:
+			messages = append(messages, fluentdEntriesMessages(t, []interface{}{chunk[1:3]}))
+		case FluentdPackedForward:
+			d := msgpack.NewDecoder(bytes.NewReader(chunk[1].([]byte)))
+
+			entries := []interface{}{}
+
+			for {
+				entry, err := d.Decode()
+				if err != nil {
+					break
+				}
+
+				entries = append(entries, entry)
+			}
+
+			messages = append(messages, fluentdEntriesMessages(t, entries))
+		default:
+			messages = append(messages, fluentdEntriesMessages(t, chunk[1].([]interface{})))
+		}
+	}
+
+	return tags, messages
+}
+
+func TestFluentd(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *FluentdOptions
+		drop         int
+		wantTags     []string
+		wantMessages [][]string
+		wantChunks   int
+	}{
+		{
+			name:         "Should work - forward",
+			opts:         &FluentdOptions{},
+			wantTags:     []string{"svc", "db"},
+			wantMessages: [][]string{{"1", "3"}, {"2"}},
+			wantChunks:   2,
+		},
+		{
+			name:         "Should work - packed forward",
+			opts:         &FluentdOptions{Mode: FluentdPackedForward},
+			wantTags:     []string{"svc", "db"},
+			wantMessages: [][]string{{"1", "3"}, {"2"}},
+			wantChunks:   2,
+		},
+		{
+			name:         "Should work - message",
+			opts:         &FluentdOptions{Mode: FluentdMessage},
+			wantTags:     []string{"svc", "db", "svc"},
+			wantMessages: [][]string{{"1"}, {"2"}, {"3"}},
+			wantChunks:   3,
+		},
+		{
+			name:         "Should work - ack, and retry",
+			opts:         &FluentdOptions{RequireAck: true, MinBackoff: time.Millisecond},
+			drop:         1,
+			wantTags:     []string{"svc", "db"},
+			wantMessages: [][]string{{"1", "3"}, {"2"}},
+			wantChunks:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeFluentd(t, tt.drop)
+
+			tt.opts.Batch = BatchOptions{Interval: time.Hour}
+
+			w := NewFluentdWriter("tcp", f.listener.Addr().String(), tt.opts)
+
+			o := New("Fluentd", level.Trace, w)
+
+			for _, m := range []message.IMessage{
+				message.New(level.Info, "1").SetComponentName("svc"),
+				message.New(level.Info, "2").SetComponentName("db"),
+				message.New(level.Info, "3").SetComponentName("svc"),
+			} {
+				if err := o.Write(m); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if err := o.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			chunks := f.Chunks(tt.wantChunks)
+
+			if len(chunks) != tt.wantChunks {
+				t.Fatalf("Got %d chunks, want %d", len(chunks), tt.wantChunks)
+			}
+
+			// Dropped chunks are retried.
+			tags, messages := fluentdChunks(t, tt.opts.Mode, chunks[tt.drop:])
+
+			if !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("Got %v, want %v", tags, tt.wantTags)
+			}
+
+			if !reflect.DeepEqual(messages, tt.wantMessages) {
+				t.Errorf("Got %v, want %v", messages, tt.wantMessages)
+			}
+
+			if w.GetSent() != 3 {
+				t.Errorf("Got %d sent, want 3", w.GetSent())
+			}
+		})
+	}
+}
+
+func TestFluentd_Record(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 51, 46, 89123456, time.UTC)
+
+	m := message.New(level.Warn, "Test\n")
+	m.SetComponentName("svc")
+	m.SetOutputName("Fluentd")
+	m.SetTimestamp(ts)
+	m.SetFields(fields.Fields{"user": fields.Fields{"id": 1}, "level": "should not override"})
+
+	record, err := msgpack.NewDecoder(bytes.NewReader(fluentdRecord(m))).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+
+	want := map[string]interface{}{
+		"component": "svc",
+		"level":     "warn",
+		"message":   "Test",
+		"output":    "Fluentd",
+		"user":      map[string]interface{}{"id": uint64(1)},
+	}
+
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("Got %v, want %v", record, want)
+	}
+
+	var b []byte
+
+	b = appendFluentdEntry(b, fluentdEntry{timestamp: ts})
+
+	if got := binary.BigEndian.Uint32(b[7:11]); got != uint32(ts.Nanosecond()) {
+		t.Errorf("Got %d nanoseconds, want %d", got, ts.Nanosecond())
+	}
+}
+
+func TestFluentdTag(t *testing.T) {
+	m := message.New(level.Info, "Test")
+	m.SetComponentName("svc")
+	m.SetOutputName("Fluentd")
+	m.AddTags("a", "b")
+
+	tests := []struct {
+		name   string
+		prefix string
+		parts  []FluentdTagPart
+		want   string
+	}{
+		{name: "Should work", prefix: "", parts: []FluentdTagPart{FluentdTagComponent}, want: "svc"},
+		{name: "Should work - prefix", prefix: "app", parts: []FluentdTagPart{FluentdTagComponent}, want: "app.svc"},
+		{
+			name:   "Should work - all parts",
+			prefix: "",
+			parts:  []FluentdTagPart{FluentdTagComponent, FluentdTagOutput, FluentdTagTags},
+			want:   "svc.fluentd.a.b",
+		},
+		{name: "Should work - default", prefix: "", parts: nil, want: DefaultFluentdTag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FluentdTag(tt.prefix, tt.parts...)(m); got != tt.want {
+				t.Errorf("Got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GELF defaults.
+const (
+	// DefaultGELFChunkSize is suitable for WANs. Use 8192 for LANs.
+	DefaultGELFChunkSize = 1420
+
+	// GELFMaxChunks is the max number of chunks of a GELF message.
+	GELFMaxChunks = 128
+)
+
+// Chunked GELF header: magic bytes, message ID, sequence number, and count.
+const gelfChunkHeaderSize = 12
+
+// Chunked GELF magic bytes.
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFCompression is the compression of GELF messages sent over UDP.
+type GELFCompression int
+
+const (
+	// GELFGzip is the gzip compression.
+	GELFGzip GELFCompression = iota
+
+	// GELFZlib is the zlib compression.
+	GELFZlib
+
+	// GELFNoCompression disables compression.
+	GELFNoCompression
+)
+
+// GELFOptions are options for the `GELFWriter`. Zero values fallback to the
+// defaults.
+type GELFOptions struct {
+	// ChunkSize is the max size of UDP datagrams, including the chunk header.
+	// Larger messages are chunked. Default is `DefaultGELFChunkSize`.
+	ChunkSize int
+
+	// Compression of messages sent over UDP. Default is `GELFGzip`. Messages
+	// sent over TCP aren't compressed, as GELF doesn't support it.
+	Compression GELFCompression
+
+	// NetworkOptions are options for the underlying `NetworkWriter`. The
+	// delimiter is always the null byte.
+	NetworkOptions *NetworkOptions
+}
+
+// GELFWriter sends GELF messages - formatted by the `GELF` formatter, to
+// Graylog.
+//
+// Notes:
+// - Over UDP, messages are compressed, and chunked if larger than the chunk
+// size.
+// - Over TCP, messages are null byte delimited.
+// - Connection is handled by a `NetworkWriter`: lazy, reconnecting, and
+// buffering while disconnected.
+type GELFWriter struct {
+	// Underlying connection.
+	conn *NetworkWriter
+
+	// Whether messages are sent over UDP.
+	udp bool
+
+	// Options.
+	options GELFOptions
+}
+
+// Write implements the io.Writer interface.
+func (g *GELFWriter) Write(p []byte) (int, error) {
+	payload := bytes.TrimRight(p, "\r\n")
+
+	if !g.udp {
+		if _, err := g.conn.Write(payload); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	chunks, err := g.chunk(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, chunk := range chunks {
+		if _, err := g.conn.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush sends buffered messages, if connected.
+func (g *GELFWriter) Flush() error {
+	return g.conn.Flush()
+}
+
+// Close closes the connection.
+func (g *GELFWriter) Close() error {
+	return g.conn.Close()
+}
+
+// State returns the state of the connection.
+func (g *GELFWriter) State() ConnState {
+	return g.conn.State()
+}
+
+//////
+// Helpers.
+//////
+
+// Compresses the payload.
+func (g *GELFWriter) compress(payload []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+
+	switch g.options.Compression {
+	case GELFNoCompression:
+		return payload, nil
+	case GELFZlib:
+		w = zlib.NewWriter(&buf)
+	default:
+		w = gzip.NewWriter(&buf)
+	}
+
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Compresses the payload, and splits it into chunks if larger than the chunk
+// size.
+func (g *GELFWriter) chunk(payload []byte) ([][]byte, error) {
+	compressed, err := g.compress(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(compressed) <= g.options.ChunkSize {
+		return [][]byte{compressed}, nil
+	}
+
+	size := g.options.ChunkSize - gelfChunkHeaderSize
+	count := (len(compressed) + size - 1) / size
+
+	if count > GELFMaxChunks {
+		return nil, fmt.Errorf("%w: %d chunks, max %d", ErrGELFTooLarge, count, GELFMaxChunks)
+	}
+
+	id := make([]byte, 8)
+
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	chunks := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+
+		if end > len(compressed) {
+			end = len(compressed)
+		}
+
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*size)
+		chunk = append(chunk, gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, compressed[i*size:end]...)
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+//////
+// Factory.
+//////
+
+// NewGELFWriter is the `GELFWriter` factory. `network` is either `udp`, or
+// `tcp` (and variants). `opts` is optional.
+func NewGELFWriter(network, address string, opts *GELFOptions) *GELFWriter {
+	g := &GELFWriter{udp: strings.HasPrefix(network, "udp")}
+
+	if opts != nil {
+		g.options = *opts
+	}
+
+	if g.options.ChunkSize <= gelfChunkHeaderSize {
+		g.options.ChunkSize = DefaultGELFChunkSize
+	}
+
+	networkOptions := NetworkOptions{}
+
+	if g.options.NetworkOptions != nil {
+		networkOptions = *g.options.NetworkOptions
+	}
+
+	networkOptions.Delimiter = "\x00"
+
+	g.conn = NewNetworkWriter(network, address, &networkOptions)
+
+	return g
+}
@@ -0,0 +1,212 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Reads a datagram, or times out.
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 65536)
+
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %s", err)
+	}
+
+	return buf[:n]
+}
+
+// Decodes a GELF payload, returning the short message.
+func gelfShortMessage(t *testing.T, payload []byte) string {
+	t.Helper()
+
+	var decoded map[string]interface{}
+
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %s, payload: %q", err, payload)
+	}
+
+	shortMessage, _ := decoded["short_message"].(string)
+
+	return shortMessage
+}
+
+func TestGELF_UDP(t *testing.T) {
+	large := strings.Repeat("0123456789", 100)
+
+	tests := []struct {
+		name       string
+		opts       *GELFOptions
+		content    string
+		wantChunks int
+		decompress func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			name:       "Should work - gzip",
+			opts:       nil,
+			content:    "Test",
+			wantChunks: 1,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:       "Should work - zlib",
+			opts:       &GELFOptions{Compression: GELFZlib},
+			content:    "Test",
+			wantChunks: 1,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zlib.NewReader(r)
+			},
+		},
+		{
+			name:       "Should work - chunked",
+			opts:       &GELFOptions{ChunkSize: 512, Compression: GELFNoCompression},
+			content:    large,
+			wantChunks: 3,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return r, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("ListenPacket failed: %s", err)
+			}
+			defer conn.Close()
+
+			o := GELF("udp", conn.LocalAddr().String(), level.Trace, tt.opts)
+			defer o.Close()
+
+			if err := o.Write(message.New(level.Info, tt.content)); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			var (
+				payload []byte
+				id      []byte
+			)
+
+			for i := 0; i < tt.wantChunks; i++ {
+				datagram := readDatagram(t, conn)
+
+				if tt.wantChunks == 1 {
+					payload = datagram
+
+					break
+				}
+
+				if !bytes.Equal(datagram[:2], gelfChunkMagic) {
+					t.Fatalf("Got %x, want chunk magic bytes", datagram[:2])
+				}
+
+				if id == nil {
+					id = datagram[2:10]
+				} else if !bytes.Equal(datagram[2:10], id) {
+					t.Errorf("Got %x message ID, want %x", datagram[2:10], id)
+				}
+
+				if int(datagram[10]) != i || int(datagram[11]) != tt.wantChunks {
+					t.Errorf("Got chunk %d/%d, want %d/%d", datagram[10], datagram[11], i, tt.wantChunks)
+				}
+
+				payload = append(payload, datagram[12:]...)
+			}
+
+			r, err := tt.decompress(bytes.NewReader(payload))
+			if err != nil {
+				t.Fatalf("Decompress failed: %s", err)
+			}
+
+			decompressed, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll failed: %s", err)
+			}
+
+			if got := gelfShortMessage(t, decompressed); got != tt.content {
+				t.Errorf("Got %s, want %s", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestGELF_UDP_TooLarge(t *testing.T) {
+	w := NewGELFWriter("udp", "127.0.0.1:1", &GELFOptions{ChunkSize: 13, Compression: GELFNoCompression})
+	defer w.Close()
+
+	if _, err := w.Write(bytes.Repeat([]byte("a"), GELFMaxChunks+1)); !errors.Is(err, ErrGELFTooLarge) {
+		t.Errorf("Got %v, want %v", err, ErrGELFTooLarge)
+	}
+}
+
+func TestGELF_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+	defer l.Close()
+
+	received := make(chan []byte, 2)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		for {
+			frame, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+
+			received <- frame
+		}
+	}()
+
+	o := GELF("tcp", l.Addr().String(), level.Trace, nil)
+	defer o.Close()
+
+	for _, content := range []string{"1", "2\n"} {
+		if err := o.Write(message.New(level.Info, content)); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+
+	for _, want := range []string{"1", "2"} {
+		select {
+		case frame := <-received:
+			if got := gelfShortMessage(t, bytes.TrimSuffix(frame, []byte{0})); got != want {
+				t.Errorf("Got %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the message")
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// HTTP defaults.
+const (
+	DefaultHTTPMaxBackoff = 10 * time.Second
+	DefaultHTTPMaxRetries = 3
+	DefaultHTTPMinBackoff = 100 * time.Millisecond
+	DefaultHTTPTimeout    = 10 * time.Second
+)
+
+// HTTPOptions are options for the `HTTPWriter`. Zero values fallback to the
+// defaults.
+type HTTPOptions struct {
+	// Batch options.
+	Batch BatchOptions
+
+	// Client used to send requests. Default is a client with a
+	// `DefaultHTTPTimeout` timeout.
+	Client *http.Client
+
+	// Compress, if true, the body is gzip compressed.
+	Compress bool
+
+	// Fallback, if set, messages of batches which permanently failed are
+	// written to it, as sent - processed, and formatted. Its own processors,
+	// and formatter also run, thus usually it has no formatter. Otherwise, the
+	// failure is reported.
+	//
+	// Note: It isn't closed by the `HTTPWriter`.
+	Fallback IOutput
+
+	// Header is added to requests, e.g.: `Authorization`.
+	Header http.Header
+
+	// MaxBackoff is the max interval between retries. Default is
+	// `DefaultHTTPMaxBackoff`.
+	MaxBackoff time.Duration
+
+	// MaxRetries is the max number of retries. Default is
+	// `DefaultHTTPMaxRetries`. Negative disables retrying.
+	MaxRetries int
+
+	// MaxRetryAfter is the max wait, requested by the `Retry-After` header,
+	// honored. If the server requests a longer one, the batch permanently
+	// fails, instead of being retried early. Default is `MaxBackoff`.
+	MaxRetryAfter time.Duration
+
+	// MinBackoff is the initial interval between retries, it doubles on each
+	// retry. Default is `DefaultHTTPMinBackoff`.
+	MinBackoff time.Duration
+
+	// Closed when the writer is closing. Stops waiting to retry, so closing
+	// isn't blocked by a long backoff.
+	stop <-chan struct{}
+}
+
+// A batched message.
+type httpItem struct {
+	// Formatted, new line terminated, message.
+	line []byte
+
+	// Message to be written to the fallback output, if any.
+	message message.IMessage
+}
+
+// HTTPWriter POSTs messages to an HTTP endpoint, e.g.: a log collector. It's a
+// message-aware writer.
+//
+// Notes:
+// - Messages are batched by count, size, and time (see `BatchOptions`), and
+// sent as NDJSON (one message per line), optionally gzip compressed.
+// - Failed requests are retried with exponential backoff on network errors,
+// 429, and 5xx responses, honoring the `Retry-After` header.
+// - Messages of batches which permanently failed are written to the fallback
+// output, if any.
+// - Call `Flush` to send pending messages, and `Close` to stop.
+type HTTPWriter struct {
+	// Endpoint.
+	url string
+
+	// Options.
+	options HTTPOptions
+
+	// Batches messages.
+	batcher *batcher[httpItem]
+
+	// Counters.
+	failed uint64
+	sent   uint64
+}
+
+// Write implements the io.Writer interface.
+//
+// Note: `p` can't be written to the fallback output.
+func (h *HTTPWriter) Write(p []byte) (int, error) {
+	if err := h.add(httpItem{line: terminate(p)}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (h *HTTPWriter) WriteMessage(m message.IMessage) error {
+	item := httpItem{line: terminate([]byte(m.GetContent().GetProcessed()))}
+
+	// Fallback output should get the processed content, e.g.: redacted.
+	if h.options.Fallback != nil {
+		item.message = snapshot(m)
+	}
+
+	return h.add(item)
+}
+
+// Flush sends pending messages, and blocks until they are sent, or failed.
+func (h *HTTPWriter) Flush() error {
+	h.batcher.flush()
+
+	return nil
+}
+
+// Close sends pending messages, and stops. It's safe to call it multiple
+// times.
+func (h *HTTPWriter) Close() error {
+	h.batcher.close()
+
+	return nil
+}
+
+// GetFailed returns the number of messages which failed to be sent.
+func (h *HTTPWriter) GetFailed() uint64 {
+	return atomic.LoadUint64(&h.failed)
+}
+
+// GetSent returns the number of sent messages.
+func (h *HTTPWriter) GetSent() uint64 {
+	return atomic.LoadUint64(&h.sent)
+}
+
+//////
+// Helpers.
+//////
+
+// Adds the item to the current batch.
+func (h *HTTPWriter) add(item httpItem) error {
+	return h.batcher.add(item, len(item.line))
+}
+
+// Sends the batch. On failure, messages are written to the fallback output.
+func (h *HTTPWriter) send(items []httpItem) {
+	var buf bytes.Buffer
+
+	for _, item := range items {
+		// Writing to a `bytes.Buffer` doesn't fail.
+		_, _ = buf.Write(item.line)
+	}
+
+	header := h.options.Header.Clone()
+
+	if header == nil {
+		header = http.Header{}
+	}
+
+	header.Set("Content-Type", "application/x-ndjson")
+
+	body := buf.Bytes()
+
+	var err error
+
+	if h.options.Compress {
+		header.Set("Content-Encoding", "gzip")
+
+		body, err = gzipCompress(body)
+	}
+
+	if err == nil {
+		err = postWithRetry(h.options, h.url, header, body)
+	}
+
+	if err != nil {
+		atomic.AddUint64(&h.failed, uint64(len(items)))
+
+		messages := make([]message.IMessage, 0, len(items))
+
+		for _, item := range items {
+			messages = append(messages, item.message)
+		}
+
+		writeToFallback("HTTP", h.options.Fallback, messages, err)
+
+		return
+	}
+
+	atomic.AddUint64(&h.sent, uint64(len(items)))
+}
+
+// Returns options with defaults applied.
+func (opts HTTPOptions) withDefaults() HTTPOptions {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultHTTPMaxBackoff
+	}
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultHTTPMaxRetries
+	}
+
+	if opts.MaxRetryAfter <= 0 {
+		opts.MaxRetryAfter = opts.MaxBackoff
+	}
+
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultHTTPMinBackoff
+	}
+
+	return opts
+}
+
+// Writes messages of a batch which permanently failed to the fallback output.
+// Without fallback, the failure is reported. Nil messages are skipped.
+func writeToFallback(name string, fallback IOutput, messages []message.IMessage, err error) {
+	if fallback == nil {
+		log.Println(shared.ErrorPrefix, fmt.Sprintf("%s Output: Failed to send %d messages:", name, len(messages)), err)
+
+		return
+	}
+
+	for _, m := range messages {
+		if m != nil {
+			// Errors are already reported by the fallback output.
+			_ = fallback.Write(m)
+		}
+	}
+}
+
+// POSTs the body, retrying with exponential backoff, up to `MaxBackoff`, on
+// network errors, 429, and 5xx responses. The `Retry-After` header is honored,
+// up to `MaxRetryAfter`. Waiting stops if the writer is closing.
+func postWithRetry(opts HTTPOptions, url string, header http.Header, body []byte) error {
+	backoff := opts.MinBackoff
+
+	for attempt := 0; ; attempt++ {
+		wait, err := post(opts.Client, url, header, body)
+		if err == nil {
+			return nil
+		}
+
+		if wait < 0 || attempt >= opts.MaxRetries {
+			return err
+		}
+
+		if wait > opts.MaxRetryAfter {
+			return fmt.Errorf("%w: Retry-After %s exceeds %s", err, wait, opts.MaxRetryAfter)
+		}
+
+		if wait == 0 {
+			wait = backoff
+
+			if wait > opts.MaxBackoff {
+				wait = opts.MaxBackoff
+			}
+
+			backoff *= 2
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-timer.C:
+		case <-opts.stop:
+			timer.Stop()
+
+			return fmt.Errorf("%w: closed while waiting to retry", err)
+		}
+	}
+}
+
+// POSTs the body. On failure, returns how long to wait before retrying: zero
+// for the default backoff, or negative if it shouldn't be retried.
+func post(client *http.Client, url string, header http.Header, body []byte) (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+
+	req.Header = header.Clone()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	// Allows connection reuse.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("%w: %s", ErrHTTPStatus, resp.Status)
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return -1, err
+	}
+
+	return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), err
+}
+
+// Parses the `Retry-After` header - seconds, or HTTP date. Returns zero if
+// not set, or invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(v); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// Returns a copy of `p`, new line terminated.
+func terminate(p []byte) []byte {
+	line := make([]byte, len(p), len(p)+1)
+	copy(line, p)
+
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		line = append(line, '\n')
+	}
+
+	return line
+}
+
+// Compresses the body with gzip.
+func gzipCompress(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+
+	if _, err := gz.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//////
+// Factory.
+//////
+
+// NewHTTPWriter is the `HTTPWriter` factory. `opts` is optional.
+func NewHTTPWriter(url string, opts *HTTPOptions) *HTTPWriter {
+	h := &HTTPWriter{url: url}
+
+	if opts != nil {
+		h.options = *opts
+	}
+
+	h.options = h.options.withDefaults()
+
+	h.batcher = newBatcher(h.options.Batch, h.send)
+	h.options.stop = h.batcher.closing
+
+	return h
+}
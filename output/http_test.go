@@ -0,0 +1,340 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/formatter"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/processor"
+)
+
+// HTTP collector which responds with the specified statuses, in order - then
+// 200, and records received lines.
+type collector struct {
+	mu         sync.Mutex
+	attempts   int
+	encoding   string
+	lines      []string
+	retryAfter string
+	statuses   []int
+}
+
+func (c *collector) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempts++
+
+	if len(c.statuses) > 0 {
+		status := c.statuses[0]
+		c.statuses = c.statuses[1:]
+
+		retryAfter := c.retryAfter
+
+		if retryAfter == "" {
+			retryAfter = "0"
+		}
+
+		rw.Header().Set("Retry-After", retryAfter)
+		rw.WriteHeader(status)
+
+		return
+	}
+
+	c.encoding = r.Header.Get("Content-Encoding")
+
+	var body io.Reader = r.Body
+
+	if c.encoding == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		body = gz
+	}
+
+	scanner := bufio.NewScanner(body)
+
+	for scanner.Scan() {
+		c.lines = append(c.lines, scanner.Text())
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	tests := []struct {
+		name          string
+		compress      bool
+		maxRetryAfter time.Duration
+		retryAfter    string
+		statuses      []int
+		wantAttempts  int
+		wantElapsed   time.Duration
+		wantEncoding  string
+		wantFallback  string
+		wantLines     []string
+	}{
+		{
+			name:         "Should work",
+			wantAttempts: 1,
+			wantLines:    []string{"1", "2", "3"},
+		},
+		{
+			name:         "Should work - gzip",
+			compress:     true,
+			wantAttempts: 1,
+			wantEncoding: "gzip",
+			wantLines:    []string{"1", "2", "3"},
+		},
+		{
+			name:         "Should work - retry",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			wantAttempts: 3,
+			wantLines:    []string{"1", "2", "3"},
+		},
+		{
+			name:          "Should work - retry, Retry-After over max backoff",
+			maxRetryAfter: 2 * time.Second,
+			retryAfter:    "1",
+			statuses:      []int{http.StatusTooManyRequests},
+			wantAttempts:  2,
+			wantElapsed:   time.Second,
+			wantLines:     []string{"1", "2", "3"},
+		},
+		{
+			name:         "Should work - fallback, Retry-After over default max",
+			retryAfter:   "1",
+			statuses:     []int{http.StatusTooManyRequests},
+			wantAttempts: 1,
+			wantFallback: "123",
+		},
+		{
+			name:         "Should work - fallback, retries exhausted",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantAttempts: 3,
+			wantFallback: "123",
+		},
+		{
+			name:         "Should work - fallback, not retryable",
+			statuses:     []int{http.StatusBadRequest},
+			wantAttempts: 1,
+			wantFallback: "123",
+		},
+		{
+			name:          "Should work - fallback, Retry-After over max",
+			maxRetryAfter: time.Second,
+			retryAfter:    "60",
+			statuses:      []int{http.StatusTooManyRequests},
+			wantAttempts:  1,
+			wantFallback:  "123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &collector{retryAfter: tt.retryAfter, statuses: tt.statuses}
+
+			srv := httptest.NewServer(c)
+			defer srv.Close()
+
+			buf, fallback := SafeBuffer(level.Trace)
+
+			o := New("HTTP", level.Trace, NewHTTPWriter(srv.URL, &HTTPOptions{
+				Batch:         BatchOptions{Interval: time.Hour},
+				Compress:      tt.compress,
+				Fallback:      fallback,
+				MaxBackoff:    time.Millisecond,
+				MaxRetries:    2,
+				MaxRetryAfter: tt.maxRetryAfter,
+				MinBackoff:    time.Millisecond,
+			}))
+
+			start := time.Now()
+
+			for _, content := range []string{"1", "2", "3"} {
+				if err := o.Write(message.New(level.Info, content)); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if err := o.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			if elapsed := time.Since(start); elapsed < tt.wantElapsed {
+				t.Errorf("Got %s elapsed, want at least %s", elapsed, tt.wantElapsed)
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.attempts != tt.wantAttempts {
+				t.Errorf("Got %d attempts, want %d", c.attempts, tt.wantAttempts)
+			}
+
+			if c.encoding != tt.wantEncoding {
+				t.Errorf("Got %q encoding, want %q", c.encoding, tt.wantEncoding)
+			}
+
+			if !reflect.DeepEqual(c.lines, tt.wantLines) {
+				t.Errorf("Got %v, want %v", c.lines, tt.wantLines)
+			}
+
+			if buf.String() != tt.wantFallback {
+				t.Errorf("Got %q, want %q", buf.String(), tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestHTTP_Fallback(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusBadRequest}}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	buf, fallback := SafeBuffer(level.Trace, processor.Prefixer("FB> "))
+
+	o := New("HTTP", level.Trace, NewHTTPWriter(srv.URL, &HTTPOptions{
+		Batch:    BatchOptions{Interval: time.Hour},
+		Fallback: fallback,
+	}), processor.Suffixer("!"))
+
+	if err := o.Write(message.New(level.Info, "hello\n")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	// Fallback should run its own processors, on the processed content, and
+	// restore line breaks once.
+	if want := "FB> hello!\n"; buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestHTTP_FallbackRedacted(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusBadRequest}}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	buf, fallback := SafeBuffer(level.Trace)
+
+	fallback.SetFormatter(formatter.Logfmt())
+
+	o := New("HTTP", level.Trace, NewHTTPWriter(srv.URL, &HTTPOptions{
+		Batch:    BatchOptions{Interval: time.Hour},
+		Fallback: fallback,
+	}), processor.Redact(nil))
+
+	m := message.New(level.Info, "Authorization: Bearer SECRETTOKEN123 user a@b.com")
+	m.SetFields(fields.Fields{"email": "c@d.com"})
+
+	if err := o.Write(m); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if buf.String() == "" {
+		t.Fatal("Got nothing written to the fallback")
+	}
+
+	for _, secret := range []string{"SECRETTOKEN123", "a@b.com", "c@d.com"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("Got %q, want %q redacted", buf.String(), secret)
+		}
+	}
+}
+
+func TestHTTP_CloseDuringRetryAfter(t *testing.T) {
+	c := &collector{retryAfter: "60", statuses: []int{http.StatusTooManyRequests}}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	buf, fallback := SafeBuffer(level.Trace)
+
+	w := NewHTTPWriter(srv.URL, &HTTPOptions{
+		Batch:         BatchOptions{Interval: 10 * time.Millisecond},
+		Fallback:      fallback,
+		MaxRetryAfter: time.Hour,
+	})
+
+	o := New("HTTP", level.Trace, w)
+
+	if err := o.Write(message.New(level.Info, "1")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	// Waits until the batch is sent, and the writer is waiting to retry.
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		c.mu.Lock()
+		attempts := c.attempts
+		c.mu.Unlock()
+
+		if attempts > 0 {
+			break
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Got %s to close, want less than 1s", elapsed)
+	}
+
+	if buf.String() != "1" {
+		t.Errorf("Got %q, want %q", buf.String(), "1")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		v    string
+		want time.Duration
+	}{
+		{name: "Should work - not set", v: "", want: 0},
+		{name: "Should work - seconds", v: "3", want: 3 * time.Second},
+		{name: "Should work - date", v: now.Add(time.Minute).Format(http.TimeFormat), want: time.Minute},
+		{name: "Should work - past date", v: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "Should work - invalid", v: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.v, now); got != tt.want {
+				t.Errorf("Got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
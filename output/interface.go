@@ -40,6 +40,16 @@ type IOutput interface {
 	// SetMaxLevel sets the max level.
 	SetMaxLevel(l level.Level) IOutput
 
+	// GetMinLevel returns the min level.
+	GetMinLevel() level.Level
+
+	// SetMinLevel sets the min level. `None` means no min level.
+	SetMinLevel(l level.Level) IOutput
+
+	// SetLevelRange sets both, the min, and the max level. Only messages
+	// within the range - inclusive, will be written.
+	SetLevelRange(minLevel, maxLevel level.Level) IOutput
+
 	// AddProcessors adds one or more processors.
 	AddProcessors(processors ...processor.IProcessor) IOutput
 
@@ -63,4 +73,21 @@ type IOutput interface {
 
 	// Write write the message to the defined output.
 	Write(m message.IMessage) error
+
+	// Flush flushes the writer, if it supports it - implements a `Sync()
+	// error`, or a `Flush() error` method.
+	Flush() error
+
+	// Close flushes, and closes the writer, if it implements `io.Closer`.
+	// Standard output, and error are never closed. Writing to a closed output
+	// returns `ErrOutputClosed`.
+	Close() error
+}
+
+// IMessageWriter specifies a writer which is aware of the message being
+// written, not only of its processed content. If an output's writer implements
+// it, it's used instead of the Golang's builtin logger.
+type IMessageWriter interface {
+	// WriteMessage writes the message.
+	WriteMessage(m message.IMessage) error
 }
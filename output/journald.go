@@ -0,0 +1,195 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// DefaultJournaldSocket is the default journald native protocol socket.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Max length of a journal field name.
+const maxJournaldFieldNameLength = 64
+
+// JournaldOptions are options for the `JournaldWriter`.
+type JournaldOptions struct {
+	// SocketPath is the journald socket. Default is `DefaultJournaldSocket`.
+	SocketPath string
+
+	// SyslogIdentifier is used if the message has no component name. Default
+	// is the executable name.
+	SyslogIdentifier string
+}
+
+// JournaldWriter writes messages to journald using its native protocol. It's
+// a message-aware writer:
+// - Level is mapped to `PRIORITY`
+// - Component name to `SYSLOG_IDENTIFIER`
+// - Message ID to `MESSAGE_ID`
+// - Caller to `CODE_FILE`, `CODE_LINE`, and `CODE_FUNC`
+// - Fields - flattened, to uppercase journal fields, e.g.: `user.id` ->
+// `USER_ID`.
+//
+// Notes:
+// - Only supported on Linux.
+// - Payloads too large for a datagram are sent thru a sealed memory file.
+type JournaldWriter struct {
+	// Guards the connection.
+	mu sync.Mutex
+
+	// Current connection, if any. Platform specific.
+	conn journaldConn
+
+	// Options.
+	options JournaldOptions
+}
+
+// Write implements the io.Writer interface. Content is written at the
+// `Informational` priority.
+func (j *JournaldWriter) Write(p []byte) (int, error) {
+	buf := new(bytes.Buffer)
+
+	appendJournaldField(buf, "MESSAGE", strings.TrimRight(string(p), "\r\n"))
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(level.SyslogInformational))
+	appendJournaldField(buf, "SYSLOG_IDENTIFIER", j.options.SyslogIdentifier)
+
+	if err := j.send(buf.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (j *JournaldWriter) WriteMessage(m message.IMessage) error {
+	return j.send(j.encode(m))
+}
+
+//////
+// Helpers.
+//////
+
+// Encodes the message using the journald native protocol.
+func (j *JournaldWriter) encode(m message.IMessage) []byte {
+	buf := new(bytes.Buffer)
+
+	appendJournaldField(buf, "MESSAGE", strings.TrimRight(m.GetContent().GetProcessed(), "\r\n"))
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(m.GetLevel().SyslogSeverity()))
+
+	identifier := m.GetComponentName()
+
+	if identifier == "" {
+		identifier = j.options.SyslogIdentifier
+	}
+
+	appendJournaldField(buf, "SYSLOG_IDENTIFIER", identifier)
+
+	// Journald expects a 128-bit ID, formatted as lowercase hexadecimal.
+	if id := strings.ReplaceAll(m.GetID(), "-", ""); id != "" {
+		appendJournaldField(buf, "MESSAGE_ID", strings.ToLower(id))
+	}
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		appendJournaldField(buf, "CODE_FILE", caller.File)
+		appendJournaldField(buf, "CODE_LINE", strconv.Itoa(caller.Line))
+		appendJournaldField(buf, "CODE_FUNC", caller.Function)
+	}
+
+	f := fields.Flatten(m.GetFields())
+
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if name := journaldFieldName(k); name != "" {
+			appendJournaldField(buf, name, fmt.Sprint(f[k]))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// Appends a field using the journald native protocol. Values with new lines
+// are serialized as binary data, prefixed with their length.
+func appendJournaldField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+
+		return
+	}
+
+	buf.WriteString(name + "\n")
+
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+
+	buf.WriteString(value + "\n")
+}
+
+// Converts a field key into a valid journal field name: uppercase letters,
+// digits, and underscores, not starting with an underscore (reserved to
+// trusted fields), or digit, up to 64 characters. Returns empty if it can't
+// be converted.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_0123456789")
+
+	if len(name) > maxJournaldFieldNameLength {
+		name = name[:maxJournaldFieldNameLength]
+	}
+
+	return name
+}
+
+//////
+// Factory.
+//////
+
+// NewJournaldWriter is the `JournaldWriter` factory. `opts` is optional.
+func NewJournaldWriter(opts *JournaldOptions) *JournaldWriter {
+	j := &JournaldWriter{}
+
+	if opts != nil {
+		j.options = *opts
+	}
+
+	if j.options.SocketPath == "" {
+		j.options.SocketPath = DefaultJournaldSocket
+	}
+
+	if j.options.SyslogIdentifier == "" {
+		j.options.SyslogIdentifier = filepath.Base(os.Args[0])
+	}
+
+	return j
+}
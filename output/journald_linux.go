@@ -0,0 +1,152 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package output
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Unnamed datagram socket.
+type journaldConn = *net.UnixConn
+
+// Close closes the connection, if any.
+func (j *JournaldWriter) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+
+	j.conn = nil
+
+	return err
+}
+
+// Sends the payload, connecting if needed. Payloads too large for a datagram
+// are sent thru a memory file. On failure, it reconnects, and retries once.
+func (j *JournaldWriter) send(payload []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var err error
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if j.conn == nil {
+			if err = j.connect(); err != nil {
+				continue
+			}
+		}
+
+		_, _, err = j.conn.WriteMsgUnix(payload, nil, j.address())
+
+		if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+			err = j.sendFile(payload)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		_ = j.conn.Close()
+		j.conn = nil
+	}
+
+	return fmt.Errorf("journald: %w", err)
+}
+
+// Opens an unnamed datagram socket. Messages are sent to the journald socket.
+//
+// Note: Connected datagram sockets can't send file descriptors.
+func (j *JournaldWriter) connect() error {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+
+	j.conn = conn
+
+	return nil
+}
+
+// Returns the journald socket address.
+func (j *JournaldWriter) address() *net.UnixAddr {
+	return &net.UnixAddr{Name: j.options.SocketPath, Net: "unixgram"}
+}
+
+// Writes the payload to a sealed memory file, and sends its file descriptor.
+// Falls back to an unlinked temporary file in `/dev/shm`.
+func (j *JournaldWriter) sendFile(payload []byte) error {
+	f, err := journaldMemfd(payload)
+	if err != nil {
+		if f, err = journaldTempFile(payload); err != nil {
+			return err
+		}
+	}
+
+	defer f.Close()
+
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.address())
+
+	return err
+}
+
+// Returns a sealed memory file with the payload.
+func journaldMemfd(payload []byte) (*os.File, error) {
+	fd, err := unix.MemfdCreate("journal-message", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(uintptr(fd), "journal-message")
+
+	if _, err := f.Write(payload); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS,
+		unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL,
+	); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Returns an unlinked temporary file in `/dev/shm` with the payload.
+func journaldTempFile(payload []byte) (*os.File, error) {
+	f, err := os.CreateTemp("/dev/shm", "journal-message-")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	if _, err := f.Write(payload); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return f, nil
+}
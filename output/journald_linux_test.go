@@ -0,0 +1,187 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Decodes a journald native protocol payload.
+func decodeJournald(t *testing.T, payload []byte) map[string]string {
+	t.Helper()
+
+	decoded := map[string]string{}
+
+	for len(payload) > 0 {
+		i := bytes.IndexByte(payload, '\n')
+		if i == -1 {
+			t.Fatalf("Invalid payload: %q", payload)
+		}
+
+		line := string(payload[:i])
+		payload = payload[i+1:]
+
+		if name, value, ok := strings.Cut(line, "="); ok {
+			decoded[name] = value
+
+			continue
+		}
+
+		size := binary.LittleEndian.Uint64(payload[:8])
+		decoded[line] = string(payload[8 : 8+size])
+		payload = payload[8+size+1:]
+	}
+
+	return decoded
+}
+
+// Receives a datagram, reading the payload from the passed file descriptor,
+// if any.
+func receiveJournald(t *testing.T, conn *net.UnixConn) []byte {
+	t.Helper()
+
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, 1024)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("ReadMsgUnix failed: %s", err)
+	}
+
+	if oobn == 0 {
+		return buf[:n]
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		t.Fatalf("ParseSocketControlMessage failed: %s", err)
+	}
+
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil {
+		t.Fatalf("ParseUnixRights failed: %s", err)
+	}
+
+	f := os.NewFile(uintptr(fds[0]), "journal-message")
+	defer f.Close()
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %s", err)
+	}
+
+	payload, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %s", err)
+	}
+
+	return payload
+}
+
+func TestJournald(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+
+	tests := []struct {
+		name    string
+		message func() message.IMessage
+		want    map[string]string
+	}{
+		{
+			name: "Should work",
+			message: func() message.IMessage {
+				m := message.New(level.Warn, "warn\nmessage\n")
+				m.SetComponentName("component")
+				m.SetID("0b3c1c4e-9c1f-4e4a-8b7e-1d2a3f4b5c6d")
+				m.SetCaller(runtime.Frame{PC: 1, File: file, Line: line, Function: "output.TestJournald"})
+				m.SetFields(fields.Fields{
+					"user":      fields.Fields{"id": 1},
+					"_trusted":  "v",
+					"has space": "v",
+				})
+
+				return m
+			},
+			want: map[string]string{
+				"MESSAGE":           "warn\nmessage",
+				"PRIORITY":          "4",
+				"SYSLOG_IDENTIFIER": "component",
+				"MESSAGE_ID":        "0b3c1c4e9c1f4e4a8b7e1d2a3f4b5c6d",
+				"CODE_FILE":         file,
+				"CODE_LINE":         "0",
+				"CODE_FUNC":         "output.TestJournald",
+				"USER_ID":           "1",
+				"TRUSTED":           "v",
+				"HAS_SPACE":         "v",
+			},
+		},
+		{
+			name: "Should work - large payload",
+			message: func() message.IMessage {
+				m := message.New(level.Error, strings.Repeat("a", 1<<20))
+				m.SetID("")
+
+				return m
+			},
+			want: map[string]string{
+				"MESSAGE":           strings.Repeat("a", 1<<20),
+				"PRIORITY":          "3",
+				"SYSLOG_IDENTIFIER": "sypl",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "socket")
+
+			conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+			if err != nil {
+				t.Fatalf("ListenUnixgram failed: %s", err)
+			}
+
+			defer conn.Close()
+
+			o := Journald(level.Trace, &JournaldOptions{SocketPath: path, SyslogIdentifier: "sypl"})
+
+			defer o.Close()
+
+			if err := o.Write(tt.message()); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			got := decodeJournald(t, receiveJournald(t, conn))
+
+			if tt.want["CODE_LINE"] == "0" {
+				tt.want["CODE_LINE"] = got["CODE_LINE"]
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("Got %d fields, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("Got %s=%.50q, want %.50q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
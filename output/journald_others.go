@@ -0,0 +1,20 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package output
+
+// Journald is only supported on Linux.
+type journaldConn = struct{}
+
+// Close does nothing, journald is only supported on Linux.
+func (j *JournaldWriter) Close() error {
+	return nil
+}
+
+// Journald is only supported on Linux.
+func (j *JournaldWriter) send(_ []byte) error {
+	return ErrJournaldUnsupported
+}
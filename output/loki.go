@@ -0,0 +1,580 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/golang/snappy"
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/formatter"
+	"github.com/saucelabs/sypl/internal/protobuf"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+)
+
+// LokiPushPath is the Loki's push API path.
+const LokiPushPath = "/loki/api/v1/push"
+
+// Loki defaults.
+const (
+	DefaultLokiMaxLabelValueLength = 128
+	DefaultLokiMaxStreams          = 100
+)
+
+// LokiEncoding is the encoding of push requests.
+type LokiEncoding int
+
+const (
+	// LokiProtobuf is the snappy compressed protobuf encoding.
+	LokiProtobuf LokiEncoding = iota
+
+	// LokiJSON is the JSON encoding. It's gzip compressed if
+	// `HTTPOptions.Compress` is set.
+	LokiJSON
+)
+
+// LokiOptions are options for the `LokiWriter`. Zero values fallback to the
+// defaults.
+type LokiOptions struct {
+	// HTTP options: batching, client, header, retries, and fallback.
+	HTTPOptions
+
+	// DisableLineFields, if true, fields which aren't labels aren't appended
+	// to the line. Set it if the formatter already renders fields.
+	DisableLineFields bool
+
+	// Encoding. Default is `LokiProtobuf`.
+	Encoding LokiEncoding
+
+	// LabelFields are keys of fields turned into labels. Nested fields are
+	// specified with dotted keys, e.g.: `http.method`. Only use low-cardinality
+	// fields. Keys colliding with other labels, e.g.: `level`, are ignored -
+	// the fields stay in the line.
+	LabelFields []string
+
+	// Labels are static labels added to all streams, e.g.: `job`.
+	Labels map[string]string
+
+	// LabelTags, if true, message tags are turned into the `tags` label.
+	LabelTags bool
+
+	// MaxLabelValueLength is the max length of label values, longer ones are
+	// truncated. Default is `DefaultLokiMaxLabelValueLength`.
+	MaxLabelValueLength int
+
+	// MaxStreams is the max number of distinct label sets with fields, or tags
+	// labels. Once reached, messages which would create a new stream only get
+	// the component, output, level, and static labels, and their fields are
+	// appended to the line. Default is `DefaultLokiMaxStreams`.
+	MaxStreams int
+
+	// TenantID, if set, is sent as the `X-Scope-OrgID` header.
+	TenantID string
+}
+
+// A label.
+type lokiLabel struct {
+	name  string
+	value string
+}
+
+// A batched entry.
+type lokiEntry struct {
+	// Stream labels, sorted by name, and their canonical representation.
+	labels    []lokiLabel
+	labelsKey string
+
+	timestamp time.Time
+	line      string
+
+	// Message to be written to the fallback output, if any.
+	message message.IMessage
+}
+
+// A stream of entries sharing the same labels.
+type lokiStream struct {
+	labels  []lokiLabel
+	key     string
+	entries []lokiEntry
+}
+
+// LokiWriter pushes messages to Grafana Loki. It's a message-aware writer.
+//
+// Notes:
+// - Component name, output name, level, static labels, and, optionally,
+// specified fields, and tags are turned into stream labels. The line is the
+// processed content, followed by remaining fields in the logfmt format.
+// - Messages are batched (see `BatchOptions`), and grouped by label set.
+// - Labels are bounded (see `LokiOptions.MaxStreams`), so high-cardinality
+// fields don't explode streams.
+// - Failed requests are retried as the `HTTPWriter` does.
+type LokiWriter struct {
+	// Push endpoint.
+	url string
+
+	// Options.
+	options LokiOptions
+
+	// Batches entries.
+	batcher *batcher[lokiEntry]
+
+	// Guards known streams.
+	mu sync.Mutex
+
+	// Known label sets with fields, or tags labels.
+	streams map[string]bool
+
+	// Counters.
+	failed uint64
+	sent   uint64
+}
+
+// Write implements the io.Writer interface. Content is pushed with static
+// labels only.
+//
+// Note: `p` can't be written to the fallback output.
+func (l *LokiWriter) Write(p []byte) (int, error) {
+	labels := l.staticLabels()
+
+	if err := l.batcher.add(lokiEntry{
+		labels:    labels,
+		labelsKey: lokiLabelsKey(labels),
+		timestamp: time.Now(),
+		line:      strings.TrimRight(string(p), "\r\n"),
+	}, len(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (l *LokiWriter) WriteMessage(m message.IMessage) error {
+	labels, lineFields := l.labels(m)
+
+	entry := lokiEntry{
+		labels:    labels,
+		labelsKey: lokiLabelsKey(labels),
+		timestamp: m.GetTimestamp(),
+		line:      strings.TrimRight(m.GetContent().GetProcessed(), "\r\n"),
+	}
+
+	if !l.options.DisableLineFields {
+		entry.line += lokiLineFields(lineFields)
+	}
+
+	// Fallback output should process the original content.
+	if l.options.Fallback != nil {
+		entry.message = snapshot(m)
+	}
+
+	return l.batcher.add(entry, len(entry.line))
+}
+
+// Flush pushes pending messages, and blocks until they are sent, or failed.
+func (l *LokiWriter) Flush() error {
+	l.batcher.flush()
+
+	return nil
+}
+
+// Close pushes pending messages, and stops. It's safe to call it multiple
+// times.
+func (l *LokiWriter) Close() error {
+	l.batcher.close()
+
+	return nil
+}
+
+// GetFailed returns the number of messages which failed to be sent.
+func (l *LokiWriter) GetFailed() uint64 {
+	return atomic.LoadUint64(&l.failed)
+}
+
+// GetSent returns the number of sent messages.
+func (l *LokiWriter) GetSent() uint64 {
+	return atomic.LoadUint64(&l.sent)
+}
+
+//////
+// Helpers.
+//////
+
+// Returns static labels.
+func (l *LokiWriter) staticLabels() []lokiLabel {
+	labels := map[string]string{}
+
+	for k, v := range l.options.Labels {
+		labels[lokiLabelName(k)] = v
+	}
+
+	return l.sortLabels(labels)
+}
+
+// Returns the message labels, and fields which aren't labels.
+func (l *LokiWriter) labels(m message.IMessage) ([]lokiLabel, fields.Fields) {
+	base := map[string]string{}
+
+	for k, v := range l.options.Labels {
+		base[lokiLabelName(k)] = v
+	}
+
+	if m.GetComponentName() != "" {
+		base["component"] = m.GetComponentName()
+	}
+
+	base["output"] = strings.ToLower(m.GetOutputName())
+	base["level"] = strings.ToLower(m.GetLevel().String())
+
+	lineFields := fields.Flatten(m.GetFields())
+
+	extra := map[string]string{}
+
+	for _, k := range l.options.LabelFields {
+		if v, ok := lineFields[k]; ok {
+			extra[lokiLabelName(k)] = formatter.LogfmtString(v)
+		}
+	}
+
+	if tags := m.GetTags(); l.options.LabelTags && len(tags) > 0 {
+		sorted := append([]string{}, tags...)
+
+		sort.Strings(sorted)
+
+		extra["tags"] = strings.Join(sorted, ",")
+	}
+
+	if len(extra) == 0 {
+		return l.sortLabels(base), lineFields
+	}
+
+	all := map[string]string{}
+
+	for k, v := range base {
+		all[k] = v
+	}
+
+	for k, v := range extra {
+		all[k] = v
+	}
+
+	labels := l.sortLabels(all)
+
+	// Too many streams, fields stay in the line.
+	if !l.admit(lokiLabelsKey(labels)) {
+		return l.sortLabels(base), lineFields
+	}
+
+	for _, k := range l.options.LabelFields {
+		delete(lineFields, k)
+	}
+
+	return labels, lineFields
+}
+
+// Returns true if the label set is known, or there's room for a new stream.
+func (l *LokiWriter) admit(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.streams[key] {
+		return true
+	}
+
+	if len(l.streams) >= l.options.MaxStreams {
+		return false
+	}
+
+	l.streams[key] = true
+
+	return true
+}
+
+// Returns labels sorted by name, with values truncated.
+func (l *LokiWriter) sortLabels(labels map[string]string) []lokiLabel {
+	sorted := make([]lokiLabel, 0, len(labels))
+
+	for name, value := range labels {
+		if len(value) > l.options.MaxLabelValueLength {
+			value = strings.ToValidUTF8(value[:l.options.MaxLabelValueLength], "")
+		}
+
+		sorted = append(sorted, lokiLabel{name: name, value: value})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+
+	return sorted
+}
+
+// Pushes the batch. On failure, messages are written to the fallback output.
+func (l *LokiWriter) send(entries []lokiEntry) {
+	streams := groupLokiStreams(entries)
+
+	header := l.options.Header.Clone()
+
+	if header == nil {
+		header = http.Header{}
+	}
+
+	if l.options.TenantID != "" {
+		header.Set("X-Scope-OrgID", l.options.TenantID)
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+
+	if l.options.Encoding == LokiJSON {
+		header.Set("Content-Type", "application/json")
+
+		body, err = encodeLokiJSON(streams, l.options.Compress)
+
+		if l.options.Compress {
+			header.Set("Content-Encoding", "gzip")
+		}
+	} else {
+		header.Set("Content-Type", "application/x-protobuf")
+
+		body = snappy.Encode(nil, encodeLokiProtobuf(streams))
+	}
+
+	if err == nil {
+		err = postWithRetry(l.options.HTTPOptions, l.url, header, body)
+	}
+
+	if err != nil {
+		atomic.AddUint64(&l.failed, uint64(len(entries)))
+
+		messages := make([]message.IMessage, 0, len(entries))
+
+		for _, entry := range entries {
+			messages = append(messages, entry.message)
+		}
+
+		writeToFallback("Loki", l.options.Fallback, messages, err)
+
+		return
+	}
+
+	atomic.AddUint64(&l.sent, uint64(len(entries)))
+}
+
+// Groups entries by label set, preserving order.
+func groupLokiStreams(entries []lokiEntry) []*lokiStream {
+	streams := []*lokiStream{}
+	index := map[string]*lokiStream{}
+
+	for _, entry := range entries {
+		stream, ok := index[entry.labelsKey]
+		if !ok {
+			stream = &lokiStream{labels: entry.labels, key: entry.labelsKey}
+
+			index[entry.labelsKey] = stream
+			streams = append(streams, stream)
+		}
+
+		stream.entries = append(stream.entries, entry)
+	}
+
+	return streams
+}
+
+// Encodes streams as a JSON push request, optionally gzip compressed.
+func encodeLokiJSON(streams []*lokiStream, compress bool) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	req := struct {
+		Streams []jsonStream `json:"streams"`
+	}{Streams: make([]jsonStream, 0, len(streams))}
+
+	for _, stream := range streams {
+		s := jsonStream{
+			Stream: make(map[string]string, len(stream.labels)),
+			Values: make([][2]string, 0, len(stream.entries)),
+		}
+
+		for _, label := range stream.labels {
+			s.Stream[label.name] = label.value
+		}
+
+		for _, entry := range stream.entries {
+			s.Values = append(s.Values, [2]string{
+				strconv.FormatInt(entry.timestamp.UnixNano(), 10),
+				entry.line,
+			})
+		}
+
+		req.Streams = append(req.Streams, s)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil || !compress {
+		return body, err
+	}
+
+	return gzipCompress(body)
+}
+
+// Encodes streams as a protobuf push request:
+//
+//	PushRequest { repeated StreamAdapter streams = 1; }
+//	StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func encodeLokiProtobuf(streams []*lokiStream) []byte {
+	var b []byte
+
+	for _, stream := range streams {
+		b = protobuf.AppendMessageField(b, 1, func(b []byte) []byte {
+			b = protobuf.AppendStringField(b, 1, stream.key)
+
+			for _, entry := range stream.entries {
+				b = protobuf.AppendMessageField(b, 2, func(b []byte) []byte {
+					b = protobuf.AppendMessageField(b, 1, func(b []byte) []byte {
+						b = protobuf.AppendVarintField(b, 1, uint64(entry.timestamp.Unix()))
+
+						return protobuf.AppendVarintField(b, 2, uint64(entry.timestamp.Nanosecond()))
+					})
+
+					return protobuf.AppendStringField(b, 2, entry.line)
+				})
+			}
+
+			return b
+		})
+	}
+
+	return b
+}
+
+// Returns the canonical representation of labels, e.g.:
+// `{component="svc", level="info"}`.
+func lokiLabelsKey(labels []lokiLabel) string {
+	pairs := make([]string, 0, len(labels))
+
+	for _, label := range labels {
+		pairs = append(pairs, label.name+"="+strconv.Quote(label.value))
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Returns label fields, without the ones colliding with other labels - core,
+// static, or `tags`. Ignored ones are reported.
+func lokiLabelFields(opts LokiOptions) []string {
+	reserved := map[string]bool{"component": true, "level": true, "output": true}
+
+	if opts.LabelTags {
+		reserved["tags"] = true
+	}
+
+	for k := range opts.Labels {
+		reserved[lokiLabelName(k)] = true
+	}
+
+	labelFields := make([]string, 0, len(opts.LabelFields))
+
+	for _, k := range opts.LabelFields {
+		if reserved[lokiLabelName(k)] {
+			log.Println(shared.WarnPrefix, fmt.Sprintf(`Loki Output: Ignoring label field "%s", it collides with a label`, k))
+
+			continue
+		}
+
+		labelFields = append(labelFields, k)
+	}
+
+	return labelFields
+}
+
+// Sanitizes `name` to be used as a label name: `[a-zA-Z_][a-zA-Z0-9_]*`.
+// Disallowed characters, e.g.: `.`, are replaced by `_`.
+func lokiLabelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// Returns fields - sorted by key, in the logfmt format, with a leading space,
+// or empty if there's no fields.
+func lokiLineFields(f fields.Fields) string {
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	buf := new(strings.Builder)
+
+	for _, k := range keys {
+		fmt.Fprintf(buf, " %s=%s", formatter.LogfmtKey(k), formatter.LogfmtValue(f[k]))
+	}
+
+	return buf.String()
+}
+
+//////
+// Factory.
+//////
+
+// NewLokiWriter is the `LokiWriter` factory. `url` is the Loki's base URL,
+// e.g.: `http://localhost:3100`. `opts` is optional.
+func NewLokiWriter(url string, opts *LokiOptions) *LokiWriter {
+	l := &LokiWriter{
+		url: strings.TrimRight(url, "/") + LokiPushPath,
+
+		streams: map[string]bool{},
+	}
+
+	if opts != nil {
+		l.options = *opts
+	}
+
+	l.options.HTTPOptions = l.options.HTTPOptions.withDefaults()
+
+	if l.options.MaxLabelValueLength <= 0 {
+		l.options.MaxLabelValueLength = DefaultLokiMaxLabelValueLength
+	}
+
+	if l.options.MaxStreams <= 0 {
+		l.options.MaxStreams = DefaultLokiMaxStreams
+	}
+
+	l.options.LabelFields = lokiLabelFields(l.options)
+
+	l.batcher = newBatcher(l.options.Batch, l.send)
+	l.options.HTTPOptions.stop = l.batcher.closing
+
+	return l
+}
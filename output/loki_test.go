@@ -0,0 +1,274 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Loki which records push requests.
+type fakeLoki struct {
+	mu          sync.Mutex
+	bodies      [][]byte
+	contentType string
+	tenantID    string
+}
+
+func (f *fakeLoki) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != LokiPushPath {
+		rw.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+
+	f.bodies = append(f.bodies, body)
+	f.contentType = r.Header.Get("Content-Type")
+	f.tenantID = r.Header.Get("X-Scope-OrgID")
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type lokiJSONRequest struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// Returns a message as processed by a `Sypl` logger named `svc`.
+func newLokiMessage(l level.Level, content string, f fields.Fields, tags ...string) message.IMessage {
+	m := message.New(l, content)
+
+	m.SetComponentName("svc")
+	m.SetOutputName("Loki")
+	m.SetFields(f)
+	m.AddTags(tags...)
+
+	return m
+}
+
+func TestLoki(t *testing.T) {
+	type stream struct {
+		labels map[string]string
+		lines  []string
+	}
+
+	tests := []struct {
+		name     string
+		opts     *LokiOptions
+		messages []message.IMessage
+		want     []stream
+	}{
+		{
+			name: "Should work",
+			opts: &LokiOptions{Labels: map[string]string{"job": "test"}},
+			messages: []message.IMessage{
+				newLokiMessage(level.Info, "1", fields.Fields{"raw": "\xff", "user": "a"}),
+				newLokiMessage(level.Error, "2", nil),
+				newLokiMessage(level.Info, "3", nil),
+			},
+			want: []stream{
+				{
+					labels: map[string]string{"component": "svc", "job": "test", "level": "info", "output": "loki"},
+					lines:  []string{`1 raw="\xff" user=a`, "3"},
+				},
+				{
+					labels: map[string]string{"component": "svc", "job": "test", "level": "error", "output": "loki"},
+					lines:  []string{"2"},
+				},
+			},
+		},
+		{
+			name: "Should work - fields, and tags labels",
+			opts: &LokiOptions{LabelFields: []string{"http.method"}, LabelTags: true},
+			messages: []message.IMessage{
+				newLokiMessage(level.Info, "1", fields.Fields{
+					"http": map[string]interface{}{"method": "GET", "path": "/a b"},
+				}, "web", "api"),
+			},
+			want: []stream{
+				{
+					labels: map[string]string{
+						"component":   "svc",
+						"http_method": "GET",
+						"level":       "info",
+						"output":      "loki",
+						"tags":        "api,web",
+					},
+					lines: []string{`1 http.path="/a b"`},
+				},
+			},
+		},
+		{
+			name: "Should work - keys sanitized",
+			opts: &LokiOptions{},
+			messages: []message.IMessage{
+				newLokiMessage(level.Info, "1", fields.Fields{"bad key": "v", `a"=b`: 1}),
+			},
+			want: []stream{
+				{
+					labels: map[string]string{"component": "svc", "level": "info", "output": "loki"},
+					lines:  []string{"1 a__b=1 bad_key=v"},
+				},
+			},
+		},
+		{
+			name: "Should work - label fields colliding",
+			opts: &LokiOptions{LabelFields: []string{"job", "level", "user"}, Labels: map[string]string{"job": "test"}},
+			messages: []message.IMessage{
+				newLokiMessage(level.Info, "1", fields.Fields{"job": "x", "level": "y", "user": "a"}),
+			},
+			want: []stream{
+				{
+					labels: map[string]string{"component": "svc", "job": "test", "level": "info", "output": "loki", "user": "a"},
+					lines:  []string{"1 job=x level=y"},
+				},
+			},
+		},
+		{
+			name: "Should work - bounded streams",
+			opts: &LokiOptions{LabelFields: []string{"user"}, MaxStreams: 1},
+			messages: []message.IMessage{
+				newLokiMessage(level.Info, "1", fields.Fields{"user": "a"}),
+				newLokiMessage(level.Info, "2", fields.Fields{"user": "b"}),
+				newLokiMessage(level.Info, "3", fields.Fields{"user": "a"}),
+			},
+			want: []stream{
+				{
+					labels: map[string]string{"component": "svc", "level": "info", "output": "loki", "user": "a"},
+					lines:  []string{"1", "3"},
+				},
+				{
+					labels: map[string]string{"component": "svc", "level": "info", "output": "loki"},
+					lines:  []string{"2 user=b"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeLoki{}
+
+			srv := httptest.NewServer(f)
+			defer srv.Close()
+
+			tt.opts.Encoding = LokiJSON
+			tt.opts.Batch = BatchOptions{Interval: time.Hour}
+
+			o := Loki(srv.URL, level.Trace, tt.opts)
+
+			for _, m := range tt.messages {
+				if err := o.Write(m); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if err := o.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			if len(f.bodies) != 1 {
+				t.Fatalf("Got %d requests, want 1", len(f.bodies))
+			}
+
+			var req lokiJSONRequest
+
+			if err := json.Unmarshal(f.bodies[0], &req); err != nil {
+				t.Fatalf("Unmarshal failed: %s", err)
+			}
+
+			got := []stream{}
+
+			for _, s := range req.Streams {
+				lines := []string{}
+
+				for _, v := range s.Values {
+					lines = append(lines, v[1])
+				}
+
+				got = append(got, stream{labels: s.Stream, lines: lines})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoki_Protobuf(t *testing.T) {
+	f := &fakeLoki{}
+
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	o := Loki(srv.URL, level.Trace, &LokiOptions{TenantID: "tenant"})
+
+	if err := o.Write(newLokiMessage(level.Info, "Test", nil)); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if f.contentType != "application/x-protobuf" {
+		t.Errorf("Got %s, want %s", f.contentType, "application/x-protobuf")
+	}
+
+	if f.tenantID != "tenant" {
+		t.Errorf("Got %s, want %s", f.tenantID, "tenant")
+	}
+
+	body, err := snappy.Decode(nil, f.bodies[0])
+	if err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+
+	for _, want := range []string{`{component="svc", level="info", output="loki"}`, "Test"} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("Got %q, want it to contain %q", body, want)
+		}
+	}
+}
+
+func TestLokiLabelName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Should work", in: "user_id", want: "user_id"},
+		{name: "Should work - dotted", in: "http.method", want: "http_method"},
+		{name: "Should work - leading digit", in: "1st", want: "_1st"},
+		{name: "Should work - non-ASCII", in: "ação", want: "a__o"},
+		{name: "Should work - empty", in: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lokiLabelName(tt.in); got != tt.want {
+				t.Errorf("Got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Network defaults.
+const (
+	DefaultNetworkBufferSize   = 1000
+	DefaultNetworkDialTimeout  = 5 * time.Second
+	DefaultNetworkMaxBackoff   = 30 * time.Second
+	DefaultNetworkMinBackoff   = 100 * time.Millisecond
+	DefaultNetworkWriteTimeout = 5 * time.Second
+)
+
+// ConnState is the state of a network connection.
+type ConnState int
+
+const (
+	// ConnStateDisconnected means not connected yet - connection is lazy.
+	ConnStateDisconnected ConnState = iota
+
+	// ConnStateConnected means connected.
+	ConnStateConnected
+
+	// ConnStateReconnecting means the connection failed, and it's
+	// reconnecting in background. Messages are buffered meanwhile.
+	ConnStateReconnecting
+
+	// ConnStateClosed means closed.
+	ConnStateClosed
+)
+
+var connStateNames = [...]string{"Disconnected", "Connected", "Reconnecting", "Closed"}
+
+// String interface implementation.
+func (s ConnState) String() string {
+	if s < ConnStateDisconnected || s > ConnStateClosed {
+		return "Unknown"
+	}
+
+	return connStateNames[s]
+}
+
+// NetworkOptions are options for the `NetworkWriter`. Zero values fallback to
+// the defaults.
+type NetworkOptions struct {
+	// BufferSize is the max number of messages buffered while disconnected.
+	// Once full, the oldest ones are dropped. Default is
+	// `DefaultNetworkBufferSize`.
+	BufferSize int
+
+	// Delimiter is appended to messages sent over stream based networks,
+	// unless they already end with it. Default is a new line.
+	Delimiter string
+
+	// DialTimeout. Default is `DefaultNetworkDialTimeout`.
+	DialTimeout time.Duration
+
+	// MaxBackoff is the max interval between reconnection attempts. Default is
+	// `DefaultNetworkMaxBackoff`.
+	MaxBackoff time.Duration
+
+	// MinBackoff is the initial interval between reconnection attempts, it
+	// doubles on each failed attempt. Default is `DefaultNetworkMinBackoff`.
+	MinBackoff time.Duration
+
+	// TLSConfig, if set, TLS is used. Only for TCP.
+	TLSConfig *tls.Config
+
+	// WriteTimeout. Default is `DefaultNetworkWriteTimeout`.
+	WriteTimeout time.Duration
+}
+
+// NetworkWriter writes to a network connection - TCP, UDP, or Unix socket.
+//
+// Notes:
+// - It connects lazily, in background, on the first write. Writers don't wait
+// for it, messages are buffered meanwhile.
+// - If the connection fails, it reconnects in background with exponential
+// backoff. Meanwhile, messages are buffered in a bounded buffer, and sent, in
+// order, once reconnected.
+// - Use `State` for health checks.
+type NetworkWriter struct {
+	// Network, and address to connect to.
+	network string
+	address string
+
+	// Options.
+	options NetworkOptions
+
+	// Guards the connection, and its state.
+	mu sync.Mutex
+
+	// Current connection, if any.
+	conn net.Conn
+
+	// State of the connection.
+	state ConnState
+
+	// Whether it's lazily connecting. Messages are buffered meanwhile.
+	dialing bool
+
+	// Messages buffered while disconnected.
+	buffer [][]byte
+
+	// Closed on `Close`, stops reconnecting.
+	done chan struct{}
+
+	// Number of dropped messages.
+	dropped uint64
+}
+
+// Write implements the io.Writer interface. If disconnected, `p` is buffered.
+// Buffering isn't an error.
+func (n *NetworkWriter) Write(p []byte) (int, error) {
+	frame := n.frame(p)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch n.state {
+	case ConnStateClosed:
+		return 0, ErrOutputClosed
+	case ConnStateReconnecting:
+		n.enqueue(frame)
+
+		return len(p), nil
+	case ConnStateDisconnected:
+		// Buffered first, so messages are sent in order once connected.
+		n.enqueue(frame)
+
+		// Lazy connection, in background. Writers shouldn't wait for it.
+		if !n.dialing {
+			n.dialing = true
+
+			go n.connect()
+		}
+
+		return len(p), nil
+	}
+
+	if err := n.send(frame); err != nil {
+		n.enqueue(frame)
+		n.startReconnecting()
+	}
+
+	return len(p), nil
+}
+
+// Flush sends buffered messages, if connected.
+func (n *NetworkWriter) Flush() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.state != ConnStateConnected {
+		return nil
+	}
+
+	return n.flushBuffer()
+}
+
+// Close sends buffered messages, if connected, stops reconnecting, and closes
+// the connection. It's safe to call it multiple times.
+func (n *NetworkWriter) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.state == ConnStateClosed {
+		return nil
+	}
+
+	var err error
+
+	if n.state == ConnStateConnected {
+		err = n.flushBuffer()
+	}
+
+	if n.conn != nil {
+		if closeErr := n.conn.Close(); err == nil {
+			err = closeErr
+		}
+
+		n.conn = nil
+	}
+
+	n.state = ConnStateClosed
+	n.buffer = nil
+
+	close(n.done)
+
+	return err
+}
+
+// State returns the state of the connection.
+func (n *NetworkWriter) State() ConnState {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.state
+}
+
+// GetBuffered returns the number of buffered messages.
+func (n *NetworkWriter) GetBuffered() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.buffer)
+}
+
+// GetDropped returns the number of messages dropped because the buffer was
+// full.
+func (n *NetworkWriter) GetDropped() uint64 {
+	return atomic.LoadUint64(&n.dropped)
+}
+
+//////
+// Helpers.
+//////
+
+// Returns true if the network is datagram based.
+func (n *NetworkWriter) isDatagram() bool {
+	return strings.HasPrefix(n.network, "udp") || n.network == "unixgram"
+}
+
+// Returns a copy of `p`, with the delimiter, if needed.
+func (n *NetworkWriter) frame(p []byte) []byte {
+	frame := make([]byte, len(p), len(p)+len(n.options.Delimiter))
+	copy(frame, p)
+
+	if !n.isDatagram() && !bytes.HasSuffix(frame, []byte(n.options.Delimiter)) {
+		frame = append(frame, n.options.Delimiter...)
+	}
+
+	return frame
+}
+
+// Dials, using TLS if configured.
+func (n *NetworkWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: n.options.DialTimeout}
+
+	if n.options.TLSConfig != nil {
+		return tls.DialWithDialer(dialer, n.network, n.address, n.options.TLSConfig)
+	}
+
+	return dialer.Dial(n.network, n.address)
+}
+
+// Sends the frame. On failure, the connection is closed.
+//
+// Note: Must be called with the lock held, and connected.
+func (n *NetworkWriter) send(frame []byte) error {
+	_ = n.conn.SetWriteDeadline(time.Now().Add(n.options.WriteTimeout))
+
+	if _, err := n.conn.Write(frame); err != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// Sends buffered messages, in order. On failure, remaining messages are kept.
+//
+// Note: Must be called with the lock held, and connected.
+func (n *NetworkWriter) flushBuffer() error {
+	for len(n.buffer) > 0 {
+		if err := n.send(n.buffer[0]); err != nil {
+			n.startReconnecting()
+
+			return fmt.Errorf("network: %w", err)
+		}
+
+		n.buffer[0] = nil
+		n.buffer = n.buffer[1:]
+	}
+
+	return nil
+}
+
+// Buffers the frame. If full, the oldest message is dropped.
+//
+// Note: Must be called with the lock held.
+func (n *NetworkWriter) enqueue(frame []byte) {
+	if len(n.buffer) >= n.options.BufferSize {
+		n.buffer[0] = nil
+		n.buffer = n.buffer[1:]
+
+		atomic.AddUint64(&n.dropped, 1)
+	}
+
+	n.buffer = append(n.buffer, frame)
+}
+
+// Starts reconnecting in background, if not yet.
+//
+// Note: Must be called with the lock held.
+func (n *NetworkWriter) startReconnecting() {
+	if n.state == ConnStateReconnecting || n.state == ConnStateClosed {
+		return
+	}
+
+	n.state = ConnStateReconnecting
+
+	go n.reconnect()
+}
+
+// Dials, then sends buffered messages. On failure, starts reconnecting.
+func (n *NetworkWriter) connect() {
+	conn, err := n.dial()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.dialing = false
+
+	switch {
+	case n.state == ConnStateClosed:
+		if err == nil {
+			_ = conn.Close()
+		}
+	case err != nil:
+		n.startReconnecting()
+	default:
+		n.conn = conn
+		n.state = ConnStateConnected
+
+		// On failure, a new reconnection loop is started.
+		_ = n.flushBuffer()
+	}
+}
+
+// Reconnects with exponential backoff, then sends buffered messages.
+func (n *NetworkWriter) reconnect() {
+	backoff := n.options.MinBackoff
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+
+		if backoff > n.options.MaxBackoff {
+			backoff = n.options.MaxBackoff
+		}
+
+		// Shouldn't block writers while dialing.
+		conn, err := n.dial()
+		if err != nil {
+			continue
+		}
+
+		n.mu.Lock()
+
+		if n.state == ConnStateClosed {
+			n.mu.Unlock()
+
+			_ = conn.Close()
+
+			return
+		}
+
+		n.conn = conn
+		n.state = ConnStateConnected
+
+		// On failure, a new reconnection loop is started.
+		_ = n.flushBuffer()
+
+		n.mu.Unlock()
+
+		return
+	}
+}
+
+//////
+// Factory.
+//////
+
+// NewNetworkWriter is the `NetworkWriter` factory. `network` is any network
+// supported by `net.Dial`, e.g.: `tcp`, `udp`, `unix`, or `unixgram`. `opts`
+// is optional.
+func NewNetworkWriter(network, address string, opts *NetworkOptions) *NetworkWriter {
+	n := &NetworkWriter{
+		network: network,
+		address: address,
+
+		buffer: [][]byte{},
+		done:   make(chan struct{}),
+		state:  ConnStateDisconnected,
+	}
+
+	if opts != nil {
+		n.options = *opts
+	}
+
+	if n.options.BufferSize <= 0 {
+		n.options.BufferSize = DefaultNetworkBufferSize
+	}
+
+	if n.options.Delimiter == "" {
+		n.options.Delimiter = "\n"
+	}
+
+	if n.options.DialTimeout <= 0 {
+		n.options.DialTimeout = DefaultNetworkDialTimeout
+	}
+
+	if n.options.MaxBackoff <= 0 {
+		n.options.MaxBackoff = DefaultNetworkMaxBackoff
+	}
+
+	if n.options.MinBackoff <= 0 {
+		n.options.MinBackoff = DefaultNetworkMinBackoff
+	}
+
+	if n.options.WriteTimeout <= 0 {
+		n.options.WriteTimeout = DefaultNetworkWriteTimeout
+	}
+
+	return n
+}
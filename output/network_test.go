@@ -0,0 +1,311 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// TCP server which records received lines.
+type lineServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *lineServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			scanner := bufio.NewScanner(conn)
+
+			for scanner.Scan() {
+				s.mu.Lock()
+				s.lines = append(s.lines, scanner.Text())
+				s.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// Waits until `n` lines are received, or times out.
+func (s *lineServer) Lines(n int) []string {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		s.mu.Lock()
+		lines := append([]string{}, s.lines...)
+		s.mu.Unlock()
+
+		if len(lines) >= n || time.Now().After(deadline) {
+			return lines
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newLineServer(t *testing.T, address string) *lineServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	s := &lineServer{listener: l}
+
+	t.Cleanup(func() { l.Close() })
+
+	go s.serve()
+
+	return s
+}
+
+// Returns a free TCP address, with nothing listening.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	address := l.Addr().String()
+
+	l.Close()
+
+	return address
+}
+
+// Waits until the writer reaches the state, or times out.
+func waitState(w *NetworkWriter, state ConnState) ConnState {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for w.State() != state && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return w.State()
+}
+
+func TestNetwork(t *testing.T) {
+	s := newLineServer(t, "127.0.0.1:0")
+
+	w, o := Network("tcp", s.listener.Addr().String(), level.Trace, nil)
+
+	if w.State() != ConnStateDisconnected {
+		t.Errorf("Got %s, want %s (lazy connection)", w.State(), ConnStateDisconnected)
+	}
+
+	for _, content := range []string{"1", "2\n", "3"} {
+		if err := o.Write(message.New(level.Info, content)); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+
+	if state := waitState(w, ConnStateConnected); state != ConnStateConnected {
+		t.Errorf("Got %s, want %s", state, ConnStateConnected)
+	}
+
+	want := []string{"1", "2", "3"}
+
+	if got := s.Lines(len(want)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if w.State() != ConnStateClosed {
+		t.Errorf("Got %s, want %s", w.State(), ConnStateClosed)
+	}
+}
+
+func TestNetwork_SlowDial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	defer listener.Close()
+
+	// TLS handshakes never complete, until the connection is closed.
+	accepted := make(chan net.Conn, 1)
+
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	w, o := Network("tcp", listener.Addr().String(), level.Trace, &NetworkOptions{
+		DialTimeout: 5 * time.Second,
+		TLSConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+	})
+
+	// Should buffer, instead of waiting for the dial.
+	done := make(chan error, 1)
+
+	go func() {
+		for _, content := range []string{"1", "2"} {
+			if err := o.Write(message.New(level.Info, content)); err != nil {
+				done <- err
+
+				return
+			}
+		}
+
+		done <- nil
+	}()
+
+	conn := <-accepted
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Write blocked by the dial")
+	}
+
+	if w.GetBuffered() != 2 {
+		t.Errorf("Got %d buffered, want 2", w.GetBuffered())
+	}
+
+	_ = conn.Close()
+
+	if state := waitState(w, ConnStateReconnecting); state != ConnStateReconnecting {
+		t.Errorf("Got %s, want %s", state, ConnStateReconnecting)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+}
+
+func TestNetwork_Reconnect(t *testing.T) {
+	tests := []struct {
+		name        string
+		bufferSize  int
+		want        []string
+		wantDropped uint64
+	}{
+		{
+			name:        "Should work",
+			bufferSize:  0,
+			want:        []string{"1", "2", "3"},
+			wantDropped: 0,
+		},
+		{
+			name:        "Should work - drop oldest",
+			bufferSize:  2,
+			want:        []string{"2", "3"},
+			wantDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := freeAddress(t)
+
+			w, o := Network("tcp", address, level.Trace, &NetworkOptions{
+				BufferSize: tt.bufferSize,
+				MaxBackoff: 20 * time.Millisecond,
+				MinBackoff: 10 * time.Millisecond,
+			})
+
+			defer o.Close()
+
+			// Nothing listening, so messages are buffered.
+			for _, content := range []string{"1", "2", "3"} {
+				if err := o.Write(message.New(level.Info, content)); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if state := waitState(w, ConnStateReconnecting); state != ConnStateReconnecting {
+				t.Errorf("Got %s, want %s", state, ConnStateReconnecting)
+			}
+
+			if w.GetBuffered() != len(tt.want) {
+				t.Errorf("Got %d buffered, want %d", w.GetBuffered(), len(tt.want))
+			}
+
+			if w.GetDropped() != tt.wantDropped {
+				t.Errorf("Got %d dropped, want %d", w.GetDropped(), tt.wantDropped)
+			}
+
+			s := newLineServer(t, address)
+
+			if state := waitState(w, ConnStateConnected); state != ConnStateConnected {
+				t.Fatalf("Got %s, want %s", state, ConnStateConnected)
+			}
+
+			if got := s.Lines(len(tt.want)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %v, want %v", got, tt.want)
+			}
+
+			if w.GetBuffered() != 0 {
+				t.Errorf("Got %d buffered, want 0", w.GetBuffered())
+			}
+		})
+	}
+}
+
+func TestNetwork_TLS(t *testing.T) {
+	requested := make(chan string, 1)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requested <- r.URL.Path
+	}))
+	defer srv.Close()
+
+	tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig
+
+	w, o := Network("tcp", srv.Listener.Addr().String(), level.Trace, &NetworkOptions{
+		TLSConfig: tlsConfig,
+	})
+
+	defer o.Close()
+
+	// The content is an HTTP request, which proves the server could decrypt
+	// it.
+	if err := o.Write(message.New(level.Info, "GET /sypl HTTP/1.1\r\nHost: sypl\r\n\r\n")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if state := waitState(w, ConnStateConnected); state != ConnStateConnected {
+		t.Errorf("Got %s, want %s", state, ConnStateConnected)
+	}
+
+	select {
+	case path := <-requested:
+		if path != "/sypl" {
+			t.Errorf("Got %s, want %s", path, "/sypl")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the request")
+	}
+}
@@ -0,0 +1,647 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/internal/protobuf"
+	"github.com/saucelabs/sypl/message"
+)
+
+// OTLPLogsPath is the OTLP/HTTP logs path.
+const OTLPLogsPath = "/v1/logs"
+
+// OTLP defaults.
+const (
+	DefaultOTLPSpanIDKey  = "span_id"
+	DefaultOTLPTraceIDKey = "trace_id"
+)
+
+// OTLPEncoding is the encoding of export requests.
+type OTLPEncoding int
+
+const (
+	// OTLPProtobuf is the binary protobuf encoding.
+	OTLPProtobuf OTLPEncoding = iota
+
+	// OTLPJSON is the JSON protobuf encoding.
+	OTLPJSON
+)
+
+// OTLPOptions are options for the `OTLPWriter`. Zero values fallback to the
+// defaults.
+type OTLPOptions struct {
+	// HTTP options: batching, client, compression, header, retries, and
+	// fallback.
+	HTTPOptions
+
+	// Encoding. Default is `OTLPProtobuf`.
+	Encoding OTLPEncoding
+
+	// ResourceAttributes describe the entity producing logs, e.g.:
+	// `service.version`. Default `service.name` is the executable name.
+	ResourceAttributes map[string]interface{}
+
+	// SpanIDKey is the key of the field holding the span ID - hex string, or
+	// bytes. Default is `DefaultOTLPSpanIDKey`.
+	SpanIDKey string
+
+	// TraceIDKey is the key of the field holding the trace ID - hex string, or
+	// bytes. Default is `DefaultOTLPTraceIDKey`.
+	TraceIDKey string
+}
+
+// An attribute. Value is normalized, see `otlpNormalize`.
+type otlpAttribute struct {
+	key   string
+	value interface{}
+}
+
+// A batched log record.
+type otlpRecord struct {
+	// Instrumentation scope, the component name.
+	scope string
+
+	timestamp         time.Time
+	observedTimestamp time.Time
+	severityNumber    int
+	severityText      string
+	body              string
+	attributes        []otlpAttribute
+	traceID           []byte
+	spanID            []byte
+
+	// Message to be written to the fallback output, if any.
+	message message.IMessage
+}
+
+// OTLPWriter exports messages as OpenTelemetry logs, via OTLP/HTTP. It's a
+// message-aware writer.
+//
+// Notes:
+// - Level is mapped to the severity number, and text. The processed content
+// is the body. Fields are attributes, and the component name is the
+// instrumentation scope. Caller, if known, is added as `code.*` attributes.
+// - Trace, and span IDs are extracted from fields, see `OTLPOptions`.
+// - Messages are batched (see `BatchOptions`), and grouped by scope.
+// - Failed requests are retried as the `HTTPWriter` does.
+type OTLPWriter struct {
+	// Logs endpoint.
+	url string
+
+	// Options.
+	options OTLPOptions
+
+	// Normalized resource attributes.
+	resource []otlpAttribute
+
+	// Batches records.
+	batcher *batcher[otlpRecord]
+
+	// Counters.
+	failed uint64
+	sent   uint64
+}
+
+// Write implements the io.Writer interface. Content is exported without
+// severity.
+//
+// Note: `p` can't be written to the fallback output.
+func (o *OTLPWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+
+	if err := o.batcher.add(otlpRecord{
+		timestamp:         now,
+		observedTimestamp: now,
+		body:              strings.TrimRight(string(p), "\r\n"),
+	}, len(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (o *OTLPWriter) WriteMessage(m message.IMessage) error {
+	record := otlpRecord{
+		scope:             m.GetComponentName(),
+		timestamp:         m.GetTimestamp(),
+		observedTimestamp: time.Now(),
+		severityNumber:    m.GetLevel().OTelSeverityNumber(),
+		body:              strings.TrimRight(m.GetContent().GetProcessed(), "\r\n"),
+	}
+
+	if record.severityNumber != 0 {
+		record.severityText = strings.ToUpper(m.GetLevel().String())
+	}
+
+	f := fields.Fields{}
+
+	for k, v := range m.GetFields() {
+		f[k] = v
+	}
+
+	if id, ok := otlpID(f[o.options.TraceIDKey], 16); ok {
+		record.traceID = id
+
+		delete(f, o.options.TraceIDKey)
+	}
+
+	if id, ok := otlpID(f[o.options.SpanIDKey], 8); ok {
+		record.spanID = id
+
+		delete(f, o.options.SpanIDKey)
+	}
+
+	record.attributes = otlpAttributes(f)
+
+	// Should only add the caller if known.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		record.attributes = append(record.attributes,
+			otlpAttribute{"code.filepath", caller.File},
+			otlpAttribute{"code.function", caller.Function},
+			otlpAttribute{"code.lineno", int64(caller.Line)},
+		)
+	}
+
+	// Fallback output should process the original content.
+	if o.options.Fallback != nil {
+		record.message = snapshot(m)
+	}
+
+	return o.batcher.add(record, len(record.body))
+}
+
+// Flush exports pending messages, and blocks until they are sent, or failed.
+func (o *OTLPWriter) Flush() error {
+	o.batcher.flush()
+
+	return nil
+}
+
+// Close exports pending messages, and stops. It's safe to call it multiple
+// times.
+func (o *OTLPWriter) Close() error {
+	o.batcher.close()
+
+	return nil
+}
+
+// GetFailed returns the number of messages which failed to be sent.
+func (o *OTLPWriter) GetFailed() uint64 {
+	return atomic.LoadUint64(&o.failed)
+}
+
+// GetSent returns the number of sent messages.
+func (o *OTLPWriter) GetSent() uint64 {
+	return atomic.LoadUint64(&o.sent)
+}
+
+//////
+// Helpers.
+//////
+
+// Exports the batch. On failure, messages are written to the fallback output.
+func (o *OTLPWriter) send(records []otlpRecord) {
+	scopes, grouped := groupOTLPRecords(records)
+
+	header := o.options.Header.Clone()
+
+	if header == nil {
+		header = http.Header{}
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+
+	if o.options.Encoding == OTLPJSON {
+		header.Set("Content-Type", "application/json")
+
+		body, err = json.Marshal(otlpJSONRequest(o.resource, scopes, grouped))
+	} else {
+		header.Set("Content-Type", "application/x-protobuf")
+
+		body = otlpProtobufRequest(o.resource, scopes, grouped)
+	}
+
+	if err == nil && o.options.Compress {
+		header.Set("Content-Encoding", "gzip")
+
+		body, err = gzipCompress(body)
+	}
+
+	if err == nil {
+		err = postWithRetry(o.options.HTTPOptions, o.url, header, body)
+	}
+
+	if err != nil {
+		atomic.AddUint64(&o.failed, uint64(len(records)))
+
+		messages := make([]message.IMessage, 0, len(records))
+
+		for _, record := range records {
+			messages = append(messages, record.message)
+		}
+
+		writeToFallback("OTLP", o.options.Fallback, messages, err)
+
+		return
+	}
+
+	atomic.AddUint64(&o.sent, uint64(len(records)))
+}
+
+// Groups records by scope, preserving order.
+func groupOTLPRecords(records []otlpRecord) ([]string, map[string][]otlpRecord) {
+	scopes := []string{}
+	grouped := map[string][]otlpRecord{}
+
+	for _, record := range records {
+		if _, ok := grouped[record.scope]; !ok {
+			scopes = append(scopes, record.scope)
+		}
+
+		grouped[record.scope] = append(grouped[record.scope], record)
+	}
+
+	return scopes, grouped
+}
+
+// Encodes an `ExportLogsServiceRequest` in the JSON protobuf encoding.
+func otlpJSONRequest(resource []otlpAttribute, scopes []string, grouped map[string][]otlpRecord) interface{} {
+	scopeLogs := make([]interface{}, 0, len(scopes))
+
+	for _, scope := range scopes {
+		logRecords := make([]interface{}, 0, len(grouped[scope]))
+
+		for _, r := range grouped[scope] {
+			record := map[string]interface{}{
+				"timeUnixNano":         strconv.FormatInt(r.timestamp.UnixNano(), 10),
+				"observedTimeUnixNano": strconv.FormatInt(r.observedTimestamp.UnixNano(), 10),
+				"body":                 otlpJSONValue(r.body),
+				"attributes":           otlpJSONAttributes(r.attributes),
+			}
+
+			if r.severityNumber != 0 {
+				record["severityNumber"] = r.severityNumber
+				record["severityText"] = r.severityText
+			}
+
+			if r.traceID != nil {
+				record["traceId"] = hex.EncodeToString(r.traceID)
+			}
+
+			if r.spanID != nil {
+				record["spanId"] = hex.EncodeToString(r.spanID)
+			}
+
+			logRecords = append(logRecords, record)
+		}
+
+		scopeLogs = append(scopeLogs, map[string]interface{}{
+			"scope":      map[string]interface{}{"name": scope},
+			"logRecords": logRecords,
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource":  map[string]interface{}{"attributes": otlpJSONAttributes(resource)},
+				"scopeLogs": scopeLogs,
+			},
+		},
+	}
+}
+
+// Encodes attributes as JSON `KeyValue`s.
+func otlpJSONAttributes(attributes []otlpAttribute) []interface{} {
+	kvs := make([]interface{}, 0, len(attributes))
+
+	for _, a := range attributes {
+		kvs = append(kvs, map[string]interface{}{"key": a.key, "value": otlpJSONValue(a.value)})
+	}
+
+	return kvs
+}
+
+// Encodes a normalized value as a JSON `AnyValue`.
+func otlpJSONValue(v interface{}) map[string]interface{} {
+	switch value := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": value}
+	case bool:
+		return map[string]interface{}{"boolValue": value}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": otlpJSONDouble(value)}
+	case []byte:
+		return map[string]interface{}{"bytesValue": value}
+	case []interface{}:
+		values := make([]interface{}, 0, len(value))
+
+		for _, e := range value {
+			values = append(values, otlpJSONValue(e))
+		}
+
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case []otlpAttribute:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": otlpJSONAttributes(value)}}
+	}
+
+	return map[string]interface{}{}
+}
+
+// JSON can't represent NaN, and infinities as numbers.
+func otlpJSONDouble(v float64) interface{} {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+
+	return v
+}
+
+// Encodes an `ExportLogsServiceRequest` in the binary protobuf encoding.
+func otlpProtobufRequest(resource []otlpAttribute, scopes []string, grouped map[string][]otlpRecord) []byte {
+	// ExportLogsServiceRequest.resource_logs.
+	return protobuf.AppendMessageField(nil, 1, func(b []byte) []byte {
+		// ResourceLogs.resource.
+		b = protobuf.AppendMessageField(b, 1, func(b []byte) []byte {
+			return appendOTLPAttributes(b, 1, resource)
+		})
+
+		// ResourceLogs.scope_logs.
+		for _, scope := range scopes {
+			b = protobuf.AppendMessageField(b, 2, func(b []byte) []byte {
+				// ScopeLogs.scope.
+				b = protobuf.AppendMessageField(b, 1, func(b []byte) []byte {
+					return protobuf.AppendStringField(b, 1, scope)
+				})
+
+				// ScopeLogs.log_records.
+				for _, r := range grouped[scope] {
+					b = protobuf.AppendMessageField(b, 2, func(b []byte) []byte {
+						return appendOTLPRecord(b, r)
+					})
+				}
+
+				return b
+			})
+		}
+
+		return b
+	})
+}
+
+// Appends the `LogRecord` fields.
+func appendOTLPRecord(b []byte, r otlpRecord) []byte {
+	b = protobuf.AppendFixed64Field(b, 1, uint64(r.timestamp.UnixNano()))
+	b = protobuf.AppendVarintField(b, 2, uint64(r.severityNumber))
+	b = protobuf.AppendStringField(b, 3, r.severityText)
+	b = protobuf.AppendMessageField(b, 5, func(b []byte) []byte {
+		return appendOTLPValue(b, r.body)
+	})
+	b = appendOTLPAttributes(b, 6, r.attributes)
+	b = protobuf.AppendBytesField(b, 9, r.traceID)
+	b = protobuf.AppendBytesField(b, 10, r.spanID)
+
+	return protobuf.AppendFixed64Field(b, 11, uint64(r.observedTimestamp.UnixNano()))
+}
+
+// Appends attributes as the repeated `KeyValue` field `num`.
+func appendOTLPAttributes(b []byte, num int, attributes []otlpAttribute) []byte {
+	for _, a := range attributes {
+		b = protobuf.AppendMessageField(b, num, func(b []byte) []byte {
+			b = protobuf.AppendStringField(b, 1, a.key)
+
+			return protobuf.AppendMessageField(b, 2, func(b []byte) []byte {
+				return appendOTLPValue(b, a.value)
+			})
+		})
+	}
+
+	return b
+}
+
+// Appends the `AnyValue` fields of a normalized value.
+func appendOTLPValue(b []byte, v interface{}) []byte {
+	switch value := v.(type) {
+	case string:
+		// Empty strings must be set, as it's a `oneof`.
+		b = protobuf.AppendTag(b, 1, protobuf.WireBytes)
+		b = protobuf.AppendVarint(b, uint64(len(value)))
+
+		return append(b, value...)
+	case bool:
+		b = protobuf.AppendTag(b, 2, protobuf.WireVarint)
+
+		if value {
+			return protobuf.AppendVarint(b, 1)
+		}
+
+		return protobuf.AppendVarint(b, 0)
+	case int64:
+		return protobuf.AppendVarint(protobuf.AppendTag(b, 3, protobuf.WireVarint), uint64(value))
+	case float64:
+		return binary.LittleEndian.AppendUint64(protobuf.AppendTag(b, 4, protobuf.WireFixed64), math.Float64bits(value))
+	case []byte:
+		b = protobuf.AppendTag(b, 7, protobuf.WireBytes)
+		b = protobuf.AppendVarint(b, uint64(len(value)))
+
+		return append(b, value...)
+	case []interface{}:
+		return protobuf.AppendMessageField(b, 5, func(b []byte) []byte {
+			for _, e := range value {
+				b = protobuf.AppendMessageField(b, 1, func(b []byte) []byte {
+					return appendOTLPValue(b, e)
+				})
+			}
+
+			return b
+		})
+	case []otlpAttribute:
+		return protobuf.AppendMessageField(b, 6, func(b []byte) []byte {
+			return appendOTLPAttributes(b, 1, value)
+		})
+	}
+
+	// Empty `AnyValue`, e.g.: nil.
+	return b
+}
+
+// Converts fields to attributes, sorted by key.
+func otlpAttributes(f map[string]interface{}) []otlpAttribute {
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	attributes := make([]otlpAttribute, 0, len(keys))
+
+	for _, k := range keys {
+		attributes = append(attributes, otlpAttribute{k, otlpNormalize(f[k])})
+	}
+
+	return attributes
+}
+
+// Normalizes a value to one of the types supported by `AnyValue`: nil,
+// string, bool, int64, float64, []byte, []interface{}, or []otlpAttribute
+// (key-value list). `error`s, `fmt.Stringer`s, and `time.Time` are converted
+// to strings. Unsupported values are formatted, see `fmt.Sprint`.
+func otlpNormalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil, string, bool, int64, float64, []byte:
+		return value
+	case int:
+		return int64(value)
+	case int8:
+		return int64(value)
+	case int16:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case uint8:
+		return int64(value)
+	case uint16:
+		return int64(value)
+	case uint32:
+		return int64(value)
+	case uint:
+		if uint64(value) > math.MaxInt64 {
+			return float64(value)
+		}
+
+		return int64(value)
+	case uint64:
+		if value > math.MaxInt64 {
+			return float64(value)
+		}
+
+		return int64(value)
+	case float32:
+		return float64(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	case fields.Fields:
+		return otlpAttributes(value)
+	case map[string]interface{}:
+		return otlpAttributes(value)
+	}
+
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		values := make([]interface{}, 0, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, otlpNormalize(rv.Index(i).Interface()))
+		}
+
+		return values
+	}
+
+	return fmt.Sprint(v)
+}
+
+// Returns the ID of the specified size from a hex string, or bytes. IDs which
+// are invalid, or all zeros are ignored.
+func otlpID(v interface{}, size int) ([]byte, bool) {
+	var id []byte
+
+	switch value := v.(type) {
+	case string:
+		decoded, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, false
+		}
+
+		id = decoded
+	case []byte:
+		id = value
+	case [16]byte:
+		id = value[:]
+	case [8]byte:
+		id = value[:]
+	case fmt.Stringer:
+		return otlpID(value.String(), size)
+	default:
+		return nil, false
+	}
+
+	if len(id) != size || bytes.Equal(id, make([]byte, size)) {
+		return nil, false
+	}
+
+	return id, true
+}
+
+//////
+// Factory.
+//////
+
+// NewOTLPWriter is the `OTLPWriter` factory. `url` is the collector's base
+// URL, e.g.: `http://localhost:4318`. `opts` is optional.
+func NewOTLPWriter(url string, opts *OTLPOptions) *OTLPWriter {
+	o := &OTLPWriter{url: strings.TrimRight(url, "/") + OTLPLogsPath}
+
+	if opts != nil {
+		o.options = *opts
+	}
+
+	o.options.HTTPOptions = o.options.HTTPOptions.withDefaults()
+
+	if o.options.SpanIDKey == "" {
+		o.options.SpanIDKey = DefaultOTLPSpanIDKey
+	}
+
+	if o.options.TraceIDKey == "" {
+		o.options.TraceIDKey = DefaultOTLPTraceIDKey
+	}
+
+	resource := map[string]interface{}{"service.name": filepath.Base(os.Args[0])}
+
+	for k, v := range o.options.ResourceAttributes {
+		resource[k] = v
+	}
+
+	o.resource = otlpAttributes(resource)
+
+	o.batcher = newBatcher(o.options.Batch, o.send)
+	o.options.HTTPOptions.stop = o.batcher.closing
+
+	return o
+}
@@ -0,0 +1,281 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+const (
+	testTraceID = "5b8efff798038103d269b633813fc60c"
+	testSpanID  = "eee19b7ec3c1b174"
+)
+
+// OTLP collector which records export requests.
+type fakeOTLP struct {
+	mu          sync.Mutex
+	bodies      [][]byte
+	contentType string
+	encoding    string
+}
+
+func (f *fakeOTLP) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != OTLPLogsPath {
+		rw.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	var body io.Reader = r.Body
+
+	f.contentType = r.Header.Get("Content-Type")
+	f.encoding = r.Header.Get("Content-Encoding")
+
+	if f.encoding == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		body = gz
+	}
+
+	b, _ := io.ReadAll(body)
+
+	f.bodies = append(f.bodies, b)
+}
+
+type otlpJSONAnyValue struct {
+	StringValue string `json:"stringValue"`
+	IntValue    string `json:"intValue"`
+	KvlistValue *struct {
+		Values []otlpJSONKeyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+type otlpJSONKeyValue struct {
+	Key   string           `json:"key"`
+	Value otlpJSONAnyValue `json:"value"`
+}
+
+type otlpJSONExportRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []otlpJSONKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano   string             `json:"timeUnixNano"`
+				SeverityNumber int                `json:"severityNumber"`
+				SeverityText   string             `json:"severityText"`
+				Body           otlpJSONAnyValue   `json:"body"`
+				Attributes     []otlpJSONKeyValue `json:"attributes"`
+				TraceID        string             `json:"traceId"`
+				SpanID         string             `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+// Returns a message as processed by a `Sypl` logger named `component`.
+func newOTLPMessage(component string, l level.Level, content string, f fields.Fields) message.IMessage {
+	m := message.New(l, content)
+
+	m.SetComponentName(component)
+	m.SetOutputName("OTLP")
+	m.SetFields(f)
+
+	return m
+}
+
+func TestOTLP(t *testing.T) {
+	f := &fakeOTLP{}
+
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	o := OTLP(srv.URL, level.Trace, &OTLPOptions{
+		Encoding:           OTLPJSON,
+		ResourceAttributes: map[string]interface{}{"service.name": "api", "service.version": "1.0.0"},
+	})
+
+	for _, m := range []message.IMessage{
+		newOTLPMessage("svc", level.Error, "Failed\n", fields.Fields{
+			"trace_id": testTraceID,
+			"span_id":  testSpanID,
+			"user":     fields.Fields{"id": 1},
+		}),
+		newOTLPMessage("db", level.Debug, "Query", fields.Fields{"trace_id": "invalid"}),
+		newOTLPMessage("svc", level.Info, "Done", nil),
+	} {
+		if err := o.Write(m); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if f.contentType != "application/json" {
+		t.Errorf("Got %s, want %s", f.contentType, "application/json")
+	}
+
+	if len(f.bodies) != 1 {
+		t.Fatalf("Got %d requests, want 1", len(f.bodies))
+	}
+
+	var req otlpJSONExportRequest
+
+	if err := json.Unmarshal(f.bodies[0], &req); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+
+	resource := map[string]string{}
+
+	for _, a := range req.ResourceLogs[0].Resource.Attributes {
+		resource[a.Key] = a.Value.StringValue
+	}
+
+	if want := map[string]string{"service.name": "api", "service.version": "1.0.0"}; !reflect.DeepEqual(resource, want) {
+		t.Errorf("Got %v, want %v", resource, want)
+	}
+
+	scopeLogs := req.ResourceLogs[0].ScopeLogs
+
+	if len(scopeLogs) != 2 || scopeLogs[0].Scope.Name != "svc" || scopeLogs[1].Scope.Name != "db" {
+		t.Fatalf("Got %+v, want svc, and db scopes", scopeLogs)
+	}
+
+	if len(scopeLogs[0].LogRecords) != 2 {
+		t.Fatalf("Got %d svc records, want 2", len(scopeLogs[0].LogRecords))
+	}
+
+	record := scopeLogs[0].LogRecords[0]
+
+	if record.SeverityNumber != 17 || record.SeverityText != "ERROR" {
+		t.Errorf("Got %d %s, want 17 ERROR", record.SeverityNumber, record.SeverityText)
+	}
+
+	if record.Body.StringValue != "Failed" {
+		t.Errorf("Got %q, want %q", record.Body.StringValue, "Failed")
+	}
+
+	if record.TimeUnixNano == "" {
+		t.Error("Got empty timestamp")
+	}
+
+	if record.TraceID != testTraceID || record.SpanID != testSpanID {
+		t.Errorf("Got %s %s, want %s %s", record.TraceID, record.SpanID, testTraceID, testSpanID)
+	}
+
+	// IDs are removed from attributes, nested fields are key-value lists.
+	if len(record.Attributes) != 1 ||
+		record.Attributes[0].Key != "user" ||
+		record.Attributes[0].Value.KvlistValue == nil ||
+		record.Attributes[0].Value.KvlistValue.Values[0].Value.IntValue != "1" {
+		t.Errorf("Got %+v, want user.id attribute", record.Attributes)
+	}
+
+	// Invalid IDs are kept as attributes.
+	record = scopeLogs[1].LogRecords[0]
+
+	if record.TraceID != "" || len(record.Attributes) != 1 || record.Attributes[0].Value.StringValue != "invalid" {
+		t.Errorf("Got %+v, want trace_id attribute", record)
+	}
+}
+
+func TestOTLP_Protobuf(t *testing.T) {
+	f := &fakeOTLP{}
+
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	w := NewOTLPWriter(srv.URL, &OTLPOptions{HTTPOptions: HTTPOptions{Compress: true}})
+
+	o := New("OTLP", level.Trace, w)
+
+	if err := o.Write(newOTLPMessage("svc", level.Warn, "Test", fields.Fields{"trace_id": testTraceID})); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	if f.contentType != "application/x-protobuf" || f.encoding != "gzip" {
+		t.Errorf("Got %s %s, want application/x-protobuf gzip", f.contentType, f.encoding)
+	}
+
+	if w.GetSent() != 1 {
+		t.Errorf("Got %d sent, want 1", w.GetSent())
+	}
+
+	traceID, _ := hex.DecodeString(testTraceID)
+
+	want := [][]byte{
+		[]byte("svc"),
+		[]byte("Test"),
+		[]byte("WARN"),
+		// severity_number.
+		{0x10, 13},
+		// trace_id.
+		append([]byte{0x4a, 16}, traceID...),
+	}
+
+	for _, want := range want {
+		if !bytes.Contains(f.bodies[0], want) {
+			t.Errorf("Got %x, want it to contain %x", f.bodies[0], want)
+		}
+	}
+}
+
+func TestOTLPNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want interface{}
+	}{
+		{name: "Should work - int", v: 1, want: int64(1)},
+		{name: "Should work - float32", v: float32(1.5), want: 1.5},
+		{name: "Should work - error", v: errors.New("a"), want: "a"},
+		{name: "Should work - slice", v: []int{1}, want: []interface{}{int64(1)}},
+		{
+			name: "Should work - map",
+			v:    map[string]interface{}{"b": 2, "a": "1"},
+			want: []otlpAttribute{{"a", "1"}, {"b", int64(2)}},
+		},
+		{name: "Should work - unsupported", v: struct{ A int }{1}, want: "{1}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := otlpNormalize(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,8 +11,10 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 	"syscall"
 
+	"github.com/saucelabs/sypl/fields"
 	"github.com/saucelabs/sypl/flag"
 	"github.com/saucelabs/sypl/formatter"
 	"github.com/saucelabs/sypl/internal/builtin"
@@ -28,6 +30,7 @@ import (
 //
 // Notes:
 // - Any message with a `level` beyond `maxLevel` will not be written.
+// - Any message with a `level` below `minLevel` will not be written.
 // - Messages are processed according to the order processors are added.
 type output struct {
 	// Golang's builtin logger.
@@ -39,12 +42,19 @@ type output struct {
 	// Any message above the max level will not be written.
 	maxLevel level.Level
 
+	// Any message below the min level will not be written. `None` means no
+	// min level.
+	minLevel level.Level
+
 	// Name of the processor.
 	name string
 
 	// Processors used to process the message.
 	processors []processor.IProcessor
 
+	// Set to 1 when closed. Accessed atomically.
+	closed int32
+
 	// Status of the processor.
 	status status.Status
 
@@ -121,6 +131,27 @@ func (o *output) SetMaxLevel(l level.Level) IOutput {
 	return o
 }
 
+// GetMinLevel returns the min level.
+func (o *output) GetMinLevel() level.Level {
+	return o.minLevel
+}
+
+// SetMinLevel sets the min level. `None` means no min level.
+func (o *output) SetMinLevel(l level.Level) IOutput {
+	o.minLevel = l
+
+	return o
+}
+
+// SetLevelRange sets both, the min, and the max level. Only messages within
+// the range - inclusive, will be written.
+func (o *output) SetLevelRange(minLevel, maxLevel level.Level) IOutput {
+	o.minLevel = minLevel
+	o.maxLevel = maxLevel
+
+	return o
+}
+
 // AddProcessors adds one or more processors.
 func (o *output) AddProcessors(processors ...processor.IProcessor) IOutput {
 	o.processors = append(o.processors, processors...)
@@ -184,9 +215,11 @@ func (o *output) SetWriter(w io.Writer) IOutput {
 // Write the message to the defined output. In case of any error, it can be
 // introspected, providing more information about the failure. The error will be
 // the type of `ProcessingError`.
-//
-//nolint:nestif
 func (o *output) Write(m message.IMessage) error {
+	if atomic.LoadInt32(&o.closed) == 1 {
+		return ErrOutputClosed
+	}
+
 	// Should allows to specify `Output`(s).
 	processorsNames := o.GetProcessorsNames()
 
@@ -203,50 +236,120 @@ func (o *output) Write(m message.IMessage) error {
 	// Executes processors in series.
 	o.processProcessors(m, strings.Join(processorsNames, ","))
 
-	// Should print the message - regardless of the level, if flagged
-	// with `Force`.
-	if m.GetFlag() == flag.Force || m.GetFlag() == flag.SkipAndForce {
+	if o.shouldPrint(m) {
 		if err := o.write(m); err != nil {
 			log.Println(shared.ErrorPrefix, err)
 
 			return err
 		}
-	} else {
-		// Debug capability.
-		finalMaxLevel := o.GetMaxLevel()
+	}
+
+	return nil
+}
 
-		// Should only run if Debug env var is set.
-		if os.Getenv(shared.DebugEnvVar) != "" {
-			debug := m.GetDebugEnvVarRegexes()
+// Flush flushes the writer, if it supports it - implements a `Sync() error`,
+// or a `Flush() error` method.
+//
+// Note: Syncing a terminal, or a pipe isn't supported, and isn't an error.
+func (o *output) Flush() error {
+	var err error
+
+	switch w := o.GetWriter().(type) {
+	case syncer:
+		err = w.Sync()
+	case flusher:
+		err = w.Flush()
+	}
 
-			l, _, ok := debug.Level()
+	if err != nil &&
+		!errors.Is(err, syscall.EINVAL) &&
+		!errors.Is(err, syscall.ENOTSUP) &&
+		!errors.Is(err, syscall.ENOTTY) {
+		return fmt.Errorf(`output: "%s". error: "%w"`, o.GetName(), err)
+	}
 
-			if ok {
-				finalMaxLevel = l
-			}
-		}
+	return nil
+}
 
-		// Should only print if message `level` isn't above `MaxLevel`.
-		// Should only print if `level` isn't `None`.
-		// Should only print if not flagged with `Mute`.
-		if m.GetLevel() != level.None &&
-			m.GetLevel() <= finalMaxLevel &&
-			m.GetFlag() != flag.Mute {
-			if err := o.write(m); err != nil {
-				log.Println(shared.ErrorPrefix, err)
+// Close flushes, and closes the writer, if it implements `io.Closer`. Standard
+// output, and error are never closed. Writing to a closed output returns
+// `ErrOutputClosed`. It's safe to call it multiple times.
+func (o *output) Close() error {
+	if !atomic.CompareAndSwapInt32(&o.closed, 0, 1) {
+		return nil
+	}
 
-				return err
-			}
+	flushErr := o.Flush()
+
+	w := o.GetWriter()
+
+	if w == os.Stdout || w == os.Stderr {
+		return flushErr
+	}
+
+	var closeErr error
+
+	if c, ok := w.(io.Closer); ok {
+		if err := c.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			closeErr = fmt.Errorf(`output: "%s". error: "%w"`, o.GetName(), err)
 		}
 	}
 
-	return nil
+	return errors.Join(flushErr, closeErr)
 }
 
 //////
 // Helpers.
 //////
 
+// Writers which can be synced, e.g.: `*os.File`.
+type syncer interface {
+	Sync() error
+}
+
+// Writers which can be flushed, e.g.: `*bufio.Writer`.
+type flusher interface {
+	Flush() error
+}
+
+// Returns a clean copy of the message, as printed - processed content, and
+// fields, level, caller, component name, flag, ID, stack trace, tags, and
+// timestamp, which can be written to another output, e.g.: a fallback. The
+// original content isn't kept, so what processors removed, e.g.: secrets,
+// doesn't leak.
+//
+// Note: Unlike `message.Copy`, output processing state - processors names, and
+// stripped line breaks, isn't copied. Otherwise, the other output would only
+// run processors named like this output's ones, and restore line breaks twice.
+func snapshot(m message.IMessage) message.IMessage {
+	s := message.New(m.GetLevel(), m.GetContent().GetProcessed())
+
+	// Copy `options.Tags`.
+	s.GetMessage().Tags = m.GetMessage().Tags
+
+	s.AddTags(m.GetTags()...)
+
+	s.SetCaller(m.GetCaller())
+	s.SetComponentName(m.GetComponentName())
+	s.SetDebugEnvVarRegexes(m.GetDebugEnvVarRegexes())
+
+	// Fields may be changed after being printed.
+	f := make(fields.Fields, len(m.GetFields()))
+
+	for k, v := range m.GetFields() {
+		f[k] = v
+	}
+
+	s.SetFields(f)
+	s.SetFlag(m.GetFlag())
+	s.SetID(m.GetID())
+	s.SetOutputName(m.GetOutputName())
+	s.SetStackTrace(m.GetStackTrace())
+	s.SetTimestamp(m.GetTimestamp())
+
+	return s
+}
+
 // Processors logic of the Write method.
 func (o *output) processProcessors(m message.IMessage, processorsNames string) {
 	// Should not process if message is flagged with `Skip` or `SkipAndForce`.
@@ -256,6 +359,13 @@ func (o *output) processProcessors(m message.IMessage, processorsNames string) {
 			//
 			// Note: `Enabled` status is checked in the `Run` method.
 			if strings.Contains(processorsNames, p.GetName()) {
+				// Stateful processors, e.g.: `RateLimiter`, should only count
+				// messages which would be printed.
+				if sp, ok := p.(processor.IStatefulProcessor); ok && sp.IsStateful() &&
+					!o.shouldPrint(m) {
+					continue
+				}
+
 				m.SetProcessorName(p.GetName())
 
 				if err := p.Run(m); err != nil {
@@ -267,6 +377,37 @@ func (o *output) processProcessors(m message.IMessage, processorsNames string) {
 	}
 }
 
+// Returns true if the message should be printed - regardless of the level, if
+// flagged with `Force`.
+func (o *output) shouldPrint(m message.IMessage) bool {
+	if m.GetFlag() == flag.Force || m.GetFlag() == flag.SkipAndForce {
+		return true
+	}
+
+	// Debug capability. It only overrides the max level.
+	finalMaxLevel := o.GetMaxLevel()
+
+	// Should only run if Debug env var is set.
+	if os.Getenv(shared.DebugEnvVar) != "" {
+		debug := m.GetDebugEnvVarRegexes()
+
+		l, _, ok := debug.Level()
+
+		if ok {
+			finalMaxLevel = l
+		}
+	}
+
+	// Should only print if message `level` isn't above `MaxLevel`.
+	// Should only print if message `level` isn't below `MinLevel`.
+	// Should only print if `level` isn't `None`.
+	// Should only print if not flagged with `Mute`.
+	return m.GetLevel() != level.None &&
+		m.GetLevel() <= finalMaxLevel &&
+		m.GetLevel() >= o.GetMinLevel() &&
+		m.GetFlag() != flag.Mute
+}
+
 // DRY for the writing step.
 func (o *output) write(m message.IMessage) error {
 	// Should only format if any, and if not flagged.
@@ -282,25 +423,16 @@ func (o *output) write(m message.IMessage) error {
 	m.Restore()
 
 	// Write to writer.
-	if err := o.GetBuiltinLogger().OutputBuiltin(
-		builtin.DefaultCallDepth,
-		m.GetContent().GetProcessed(),
-	); err != nil {
+	if err := o.writeToWriter(m); err != nil {
 		// It means application using Sypl was piped, but the pipe was broken so
 		// nothing to do.
 		if errors.Is(err, syscall.EPIPE) {
 			return nil
 		}
 
-		// It the output passed to Sypl is already closed, nothing to do.
+		// It the writer passed to Sypl is already closed.
 		if errors.Is(err, os.ErrClosed) {
-			log.Printf(`%s Attempt to write to closed writer. Output: "%s". Error: "%v"`,
-				shared.WarnPrefix,
-				o.GetName(),
-				err,
-			)
-
-			return nil
+			err = ErrOutputClosed
 		}
 
 		return fmt.Errorf(`output: "%s". error: "%w"`, o.GetName(), err)
@@ -309,6 +441,28 @@ func (o *output) write(m message.IMessage) error {
 	return nil
 }
 
+// Writes the message to the writer. Message-aware writers are favoured over
+// the Golang's builtin logger.
+func (o *output) writeToWriter(m message.IMessage) error {
+	if mW, ok := o.GetWriter().(IMessageWriter); ok {
+		return mW.WriteMessage(m)
+	}
+
+	// Call depth varies, favour the caller captured by the logger.
+	if caller := m.GetCaller(); caller.PC != 0 {
+		return o.GetBuiltinLogger().OutputCaller(
+			caller.File,
+			caller.Line,
+			m.GetContent().GetProcessed(),
+		)
+	}
+
+	return o.GetBuiltinLogger().OutputBuiltin(
+		builtin.DefaultCallDepth,
+		m.GetContent().GetProcessed(),
+	)
+}
+
 //////
 // Factory.
 //////
@@ -322,6 +476,7 @@ func New(name string,
 	return &output{
 		builtinLogger: builtin.NewBuiltin(w, "", 0),
 		maxLevel:      maxLevel,
+		minLevel:      level.None,
 
 		name:       name,
 		processors: processors,
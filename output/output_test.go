@@ -7,8 +7,13 @@ package output
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/saucelabs/sypl/debug"
 	"github.com/saucelabs/sypl/internal/builtin"
 	"github.com/saucelabs/sypl/level"
 	"github.com/saucelabs/sypl/message"
@@ -101,3 +106,218 @@ func TestOutput_GetStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestOutput_Close(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+	}{
+		{
+			name: "Should work - File",
+			path: func(t *testing.T) string {
+				t.Helper()
+
+				return filepath.Join(t.TempDir(), "sypl.log")
+			},
+		},
+		{
+			name: "Should work - Stdout",
+			path: func(t *testing.T) string {
+				t.Helper()
+
+				return "-"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path(t)
+
+			o := File(path, level.Trace)
+
+			if err := o.Write(message.New(level.Info, shared.DefaultContentOutput)); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			if err := o.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			// Should be safe to call it multiple times.
+			if err := o.Close(); err != nil {
+				t.Fatalf("Close failed: %s", err)
+			}
+
+			if err := o.Write(message.New(level.Info, shared.DefaultContentOutput)); !errors.Is(err, ErrOutputClosed) {
+				t.Errorf("Got %v, want %v", err, ErrOutputClosed)
+			}
+
+			if path == "-" {
+				if _, err := os.Stdout.Stat(); err != nil {
+					t.Errorf("Stdout should not be closed: %s", err)
+				}
+
+				return
+			}
+
+			if _, err := o.GetWriter().Write([]byte("closed")); !errors.Is(err, os.ErrClosed) {
+				t.Errorf("File should be closed, got %v", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %s", err)
+			}
+
+			if string(content) != shared.DefaultContentOutput {
+				t.Errorf("Got %s, want %s", content, shared.DefaultContentOutput)
+			}
+		})
+	}
+}
+
+func TestOutput_Flush(t *testing.T) {
+	var buf bytes.Buffer
+
+	bufWriter := bufio.NewWriter(&buf)
+
+	o := New("Buffer", level.Trace, bufWriter)
+
+	if err := o.Write(message.New(level.Info, shared.DefaultContentOutput)); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Got %s, want nothing before flushing", buf.String())
+	}
+
+	if err := o.Flush(); err != nil {
+		t.Fatalf("Flush failed: %s", err)
+	}
+
+	if buf.String() != shared.DefaultContentOutput {
+		t.Errorf("Got %s, want %s", buf.String(), shared.DefaultContentOutput)
+	}
+}
+
+func TestOutput_LevelRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		minLevel level.Level
+		maxLevel level.Level
+		debug    string
+		want     string
+	}{
+		{
+			name:     "Should work - no min level",
+			minLevel: level.None,
+			maxLevel: level.Info,
+			want:     "fatal,error,info,",
+		},
+		{
+			name:     "Should work - range",
+			minLevel: level.Error,
+			maxLevel: level.Warn,
+			want:     "error,info,warn,",
+		},
+		{
+			name:     "Should work - single level",
+			minLevel: level.Warn,
+			maxLevel: level.Warn,
+			want:     "warn,",
+		},
+		{
+			name:     "Should work - debug env var only overrides max level",
+			minLevel: level.Info,
+			maxLevel: level.Info,
+			debug:    "trace",
+			want:     "info,warn,debug,trace,",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(shared.DebugEnvVar, tt.debug)
+
+			buf, o := SafeBuffer(level.Trace)
+
+			o.SetLevelRange(tt.minLevel, tt.maxLevel)
+
+			if o.GetMinLevel() != tt.minLevel || o.GetMaxLevel() != tt.maxLevel {
+				t.Fatalf("Got range %s-%s, want %s-%s", o.GetMinLevel(), o.GetMaxLevel(), tt.minLevel, tt.maxLevel)
+			}
+
+			for _, l := range level.LevelsNames() {
+				m := message.New(level.MustFromString(l), l+",")
+				m.SetComponentName("test")
+				m.SetOutputName(o.GetName())
+
+				if tt.debug != "" {
+					m.SetDebugEnvVarRegexes(debug.New(m.GetComponentName(), m.GetOutputName()))
+				}
+
+				if err := o.Write(m); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if buf.String() != tt.want {
+				t.Errorf("Got %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestOutput_StatefulProcessors(t *testing.T) {
+	_, rateLimiter := processor.RateLimiter(&processor.RateLimiterOptions{
+		RateLimit: processor.RateLimit{Burst: 2, Rate: 0.001},
+	})
+
+	tests := []struct {
+		name      string
+		processor processor.IProcessor
+		want      string
+	}{
+		{
+			name:      "Should work - RateLimiter",
+			processor: rateLimiter,
+			want:      "e,e,",
+		},
+		{
+			name: "Should work - Sampler",
+			processor: processor.Sampler(&processor.SamplerOptions{
+				First:      2,
+				Interval:   time.Hour,
+				Key:        func(m message.IMessage) string { return "key" },
+				Thereafter: -1,
+			}),
+			want: "e,e,",
+		},
+		{
+			name:      "Should work - Dedup",
+			processor: processor.Dedup(nil),
+			want:      "e,",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, o := SafeBuffer(level.Error, tt.processor)
+
+			// Debug messages are filtered out by level, so shouldn't affect
+			// the processor state.
+			for _, m := range []message.IMessage{
+				message.New(level.Error, "e,"),
+				message.New(level.Debug, "d,"),
+				message.New(level.Debug, "d,"),
+				message.New(level.Error, "e,"),
+			} {
+				if err := o.Write(m); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if buf.String() != tt.want {
+				t.Errorf("Got %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// DefaultRecorderMaxMessages is the default max number of messages kept by the
+// `RecorderWriter`.
+const DefaultRecorderMaxMessages = 1000
+
+// RecorderOptions are options for the `RecorderWriter`. Zero values fallback to the
+// defaults.
+type RecorderOptions struct {
+	// MaxBytes is the max size of kept messages, sum of their (processed)
+	// content length. Default is no limit.
+	MaxBytes int
+
+	// MaxMessages is the max number of kept messages. Default is
+	// `DefaultRecorderMaxMessages`. Negative means no limit, in this case
+	// `MaxBytes` should be set.
+	MaxMessages int
+}
+
+// RecorderQuery filters recorded messages. Zero values match any message.
+type RecorderQuery struct {
+	// Components matches messages from any of the components.
+	Components []string
+
+	// Levels matches messages at any of the levels.
+	Levels []level.Level
+
+	// Limit returns, at most, the specified number of most recent messages.
+	Limit int
+
+	// Since matches messages at, or after the timestamp.
+	Since time.Time
+
+	// Tags matches messages with any of the tags.
+	Tags []string
+
+	// Until matches messages before the timestamp.
+	Until time.Time
+}
+
+// Matches returns true if the message matches the query.
+func (q RecorderQuery) Matches(m message.IMessage) bool {
+	if len(q.Components) > 0 && !slices.Contains(q.Components, m.GetComponentName()) {
+		return false
+	}
+
+	if len(q.Levels) > 0 && !slices.Contains(q.Levels, m.GetLevel()) {
+		return false
+	}
+
+	if !q.Since.IsZero() && m.GetTimestamp().Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && !m.GetTimestamp().Before(q.Until) {
+		return false
+	}
+
+	if len(q.Tags) > 0 {
+		for _, tag := range q.Tags {
+			if m.ContainTag(tag) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// RecorderWriter is a flight recorder: a message-aware writer which keeps the most
+// recent messages, in memory, as structured snapshots. Recorded messages can be
+// queried, and dumped to another output on demand, e.g.: when an error occurs,
+// or a panic is recovered. It allows to cheaply record messages @ the Trace
+// level, only persisting them when something goes wrong.
+//
+// Notes:
+// - Once full - see `RecorderOptions`, the oldest messages are evicted.
+// - Snapshots hold the processed content, and fields, so what processors
+// removed, e.g.: secrets, isn't kept. Outputs which messages are dumped to
+// process, and format them as usual.
+// - Content written without message (`Write`) is recorded without level, thus
+// can't be dumped.
+type RecorderWriter struct {
+	// Options.
+	options RecorderOptions
+
+	// Guards recorded messages.
+	mu sync.Mutex
+
+	// Recorded messages, oldest first.
+	messages []message.IMessage
+
+	// Size of recorded messages.
+	size int
+
+	// Number of evicted messages.
+	evicted uint64
+}
+
+// Write implements the io.Writer interface. `p` is recorded as a message
+// without level (`None`).
+func (r *RecorderWriter) Write(p []byte) (int, error) {
+	r.record(message.New(level.None, strings.TrimRight(string(p), "\r\n")))
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (r *RecorderWriter) WriteMessage(m message.IMessage) error {
+	r.record(snapshot(m))
+
+	return nil
+}
+
+// Query returns recorded messages matching the query, oldest first. `q` is
+// optional, if nil, all recorded messages are returned.
+//
+// Note: Returned messages are the snapshots, they shouldn't be changed.
+func (r *RecorderWriter) Query(q *RecorderQuery) []message.IMessage {
+	if q == nil {
+		q = &RecorderQuery{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matches := []message.IMessage{}
+
+	// Iterates from the most recent, honoring the limit.
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(matches) == q.Limit {
+			break
+		}
+
+		if q.Matches(r.messages[i]) {
+			matches = append(matches, r.messages[i])
+		}
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+
+	return matches
+}
+
+// Dump writes recorded messages matching the query to the output, oldest
+// first. `q` is optional, if nil, all recorded messages are written. Recorded
+// messages are kept, see `Reset`.
+func (r *RecorderWriter) Dump(o IOutput, q *RecorderQuery) error {
+	var errs []error
+
+	for _, m := range r.Query(q) {
+		// Should be processed by all the output's processors.
+		msg := snapshot(m)
+
+		msg.SetOutputName(o.GetName())
+
+		if err := o.Write(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reset removes all recorded messages.
+func (r *RecorderWriter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = nil
+	r.size = 0
+}
+
+// GetEvicted returns the number of evicted messages.
+func (r *RecorderWriter) GetEvicted() uint64 {
+	return atomic.LoadUint64(&r.evicted)
+}
+
+// GetSize returns the number of recorded messages, and their size.
+func (r *RecorderWriter) GetSize() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.messages), r.size
+}
+
+//////
+// Helpers.
+//////
+
+// Records the message, evicting the oldest ones, if needed.
+func (r *RecorderWriter) record(m message.IMessage) {
+	size := len(m.GetContent().GetProcessed())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, m)
+	r.size += size
+
+	// The most recent message is always kept.
+	for len(r.messages) > 1 &&
+		((r.options.MaxMessages > 0 && len(r.messages) > r.options.MaxMessages) ||
+			(r.options.MaxBytes > 0 && r.size > r.options.MaxBytes)) {
+		r.size -= len(r.messages[0].GetContent().GetProcessed())
+
+		r.messages[0] = nil
+		r.messages = r.messages[1:]
+
+		atomic.AddUint64(&r.evicted, 1)
+	}
+}
+
+//////
+// Factory.
+//////
+
+// NewRecorderWriter is the `RecorderWriter` factory. `opts` is optional.
+func NewRecorderWriter(opts *RecorderOptions) *RecorderWriter {
+	r := &RecorderWriter{}
+
+	if opts != nil {
+		r.options = *opts
+	}
+
+	if r.options.MaxMessages == 0 {
+		r.options.MaxMessages = DefaultRecorderMaxMessages
+	}
+
+	return r
+}
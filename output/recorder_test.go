@@ -0,0 +1,230 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/formatter"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/processor"
+)
+
+// Returns the processed content of messages.
+func recordedContents(messages []message.IMessage) []string {
+	contents := []string{}
+
+	for _, m := range messages {
+		contents = append(contents, m.GetContent().GetProcessed())
+	}
+
+	return contents
+}
+
+func TestRecorder_Query(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 0, 0, 0, time.UTC)
+
+	r, o := Recorder(level.Trace, nil)
+
+	for i, m := range []message.IMessage{
+		message.New(level.Trace, "1").SetComponentName("svc"),
+		message.New(level.Error, "2").SetComponentName("db"),
+		message.New(level.Info, "3").SetComponentName("svc"),
+		message.New(level.Error, "4").SetComponentName("svc"),
+	} {
+		m.SetTimestamp(ts.Add(time.Duration(i) * time.Minute))
+
+		if i%2 == 0 {
+			m.AddTags("http")
+		}
+
+		if err := o.Write(m); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    *RecorderQuery
+		want []string
+	}{
+		{name: "Should work - all", q: nil, want: []string{"1", "2", "3", "4"}},
+		{name: "Should work - levels", q: &RecorderQuery{Levels: []level.Level{level.Error}}, want: []string{"2", "4"}},
+		{name: "Should work - components", q: &RecorderQuery{Components: []string{"db"}}, want: []string{"2"}},
+		{name: "Should work - tags", q: &RecorderQuery{Tags: []string{"http"}}, want: []string{"1", "3"}},
+		{
+			name: "Should work - time range",
+			q:    &RecorderQuery{Since: ts.Add(time.Minute), Until: ts.Add(3 * time.Minute)},
+			want: []string{"2", "3"},
+		},
+		{name: "Should work - limit", q: &RecorderQuery{Limit: 2}, want: []string{"3", "4"}},
+		{
+			name: "Should work - combined",
+			q:    &RecorderQuery{Components: []string{"svc"}, Levels: []level.Level{level.Error}, Limit: 1},
+			want: []string{"4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recordedContents(r.Query(tt.q)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_Eviction(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *RecorderOptions
+		want        []string
+		wantEvicted uint64
+		wantSize    int
+	}{
+		{
+			name:        "Should work - max messages",
+			opts:        &RecorderOptions{MaxMessages: 2},
+			want:        []string{"ccc", "dd"},
+			wantEvicted: 2,
+			wantSize:    5,
+		},
+		{
+			name:        "Should work - max bytes",
+			opts:        &RecorderOptions{MaxBytes: 6, MaxMessages: -1},
+			want:        []string{"ccc", "dd"},
+			wantEvicted: 2,
+			wantSize:    5,
+		},
+		{
+			name:        "Should work - max bytes, most recent is kept",
+			opts:        &RecorderOptions{MaxBytes: 1},
+			want:        []string{"dd"},
+			wantEvicted: 3,
+			wantSize:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, o := Recorder(level.Trace, tt.opts)
+
+			for _, content := range []string{"a", "bb", "ccc", "dd"} {
+				if err := o.Write(message.New(level.Info, content)); err != nil {
+					t.Fatalf("Write failed: %s", err)
+				}
+			}
+
+			if got := recordedContents(r.Query(nil)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %v, want %v", got, tt.want)
+			}
+
+			if r.GetEvicted() != tt.wantEvicted {
+				t.Errorf("Got %d evicted, want %d", r.GetEvicted(), tt.wantEvicted)
+			}
+
+			if _, size := r.GetSize(); size != tt.wantSize {
+				t.Errorf("Got %d size, want %d", size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestRecorder_Dump(t *testing.T) {
+	r, o := Recorder(level.Trace, nil)
+
+	f := fields.Fields{"id": 1}
+
+	for _, m := range []message.IMessage{
+		message.New(level.Trace, "1").SetFields(f),
+		message.New(level.Error, "2"),
+	} {
+		if err := o.Write(m); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+
+	// Snapshots aren't affected by later changes.
+	f["id"] = 2
+
+	buf, dst := SafeBuffer(level.Trace)
+
+	dst.SetFormatter(processor.New("Test", func(m message.IMessage) error {
+		m.GetContent().SetProcessed(fmt.Sprintf("%s %s %v\n", m.GetLevel(), m.GetContent().GetProcessed(), m.GetFields()["id"]))
+
+		return nil
+	}))
+
+	if err := r.Dump(dst, nil); err != nil {
+		t.Fatalf("Dump failed: %s", err)
+	}
+
+	if want := "trace 1 1\nerror 2 <nil>\n"; buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+
+	// Messages are kept, until reset.
+	if n, _ := r.GetSize(); n != 2 {
+		t.Errorf("Got %d messages, want 2", n)
+	}
+
+	r.Reset()
+
+	if n, size := r.GetSize(); n != 0 || size != 0 {
+		t.Errorf("Got %d messages, and %d size, want 0", n, size)
+	}
+}
+
+func TestRecorder_Dump_lineBreak(t *testing.T) {
+	r, o := Recorder(level.Trace, nil, processor.Suffixer("!"))
+
+	if err := o.Write(message.New(level.Info, "hello\n")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	buf, dst := SafeBuffer(level.Trace, processor.Prefixer("> "))
+
+	if err := r.Dump(dst, nil); err != nil {
+		t.Fatalf("Dump failed: %s", err)
+	}
+
+	// Should be dumped as printed, with a single line break.
+	if want := "> hello!\n"; buf.String() != want {
+		t.Errorf("Got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRecorder_DumpRedacted(t *testing.T) {
+	r, o := Recorder(level.Trace, nil, processor.Redact(nil))
+
+	m := message.New(level.Info, "Authorization: Bearer SECRETTOKEN123 user a@b.com")
+	m.SetFields(fields.Fields{"email": "c@d.com"})
+
+	if err := o.Write(m); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	buf, dst := SafeBuffer(level.Trace)
+
+	dst.SetFormatter(formatter.Logfmt())
+
+	if err := r.Dump(dst, nil); err != nil {
+		t.Fatalf("Dump failed: %s", err)
+	}
+
+	if buf.String() == "" {
+		t.Fatal("Got nothing dumped")
+	}
+
+	for _, secret := range []string{"SECRETTOKEN123", "a@b.com", "c@d.com"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("Got %q, want %q redacted", buf.String(), secret)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/message"
+)
+
+// SlogWriter writes messages to a `slog.Logger`. It's a message-aware writer,
+// so the message's level, timestamp, and fields are preserved.
+type SlogWriter struct {
+	logger *slog.Logger
+}
+
+// Logger returns the `slog.Logger` in use. If none was specified, it's
+// `slog.Default()` at the time of the call.
+func (s *SlogWriter) Logger() *slog.Logger {
+	if s.logger == nil {
+		return slog.Default()
+	}
+
+	return s.logger
+}
+
+// Write implements the io.Writer interface. Content is written at the `Info`
+// level.
+func (s *SlogWriter) Write(p []byte) (int, error) {
+	s.Logger().Info(strings.TrimRight(string(p), "\r\n"))
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (s *SlogWriter) WriteMessage(m message.IMessage) error {
+	ctx := context.Background()
+	logger := s.Logger()
+	l := m.GetLevel().Slog()
+
+	if !logger.Enabled(ctx, l) {
+		return nil
+	}
+
+	// `slog` expects a return address, not the address of the call.
+	var pc uintptr
+
+	if caller := m.GetCaller(); caller.PC != 0 {
+		pc = caller.PC + 1
+	}
+
+	r := slog.NewRecord(
+		m.GetTimestamp(),
+		l,
+		strings.TrimRight(m.GetContent().GetProcessed(), "\r\n"),
+		pc,
+	)
+
+	if m.GetComponentName() != "" {
+		r.AddAttrs(slog.String("component", m.GetComponentName()))
+	}
+
+	r.AddAttrs(fieldsToSlogAttrs(m.GetFields())...)
+
+	return logger.Handler().Handle(ctx, r)
+}
+
+//////
+// Helpers.
+//////
+
+// Converts fields to `slog` attributes, sorted by key. Nested fields are
+// converted to groups.
+func fieldsToSlogAttrs(f fields.Fields) []slog.Attr {
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+
+	for _, k := range keys {
+		switch v := f[k].(type) {
+		case fields.Fields:
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(fieldsToSlogAttrs(v)...)})
+		case map[string]interface{}:
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(fieldsToSlogAttrs(v)...)})
+		default:
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+
+	return attrs
+}
+
+//////
+// Factory.
+//////
+
+// NewSlogWriter is the `SlogWriter` factory. If `logger` is nil,
+// `slog.Default()` is used - resolved at write time.
+func NewSlogWriter(logger *slog.Logger) *SlogWriter {
+	return &SlogWriter{logger: logger}
+}
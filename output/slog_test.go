@@ -0,0 +1,68 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+func TestSlog(t *testing.T) {
+	tests := []struct {
+		name    string
+		message message.IMessage
+		want    string
+	}{
+		{
+			name:    "Should work",
+			message: message.New(level.Warn, "warn message\n"),
+			want:    `level=WARN msg="warn message" component=test`,
+		},
+		{
+			name: "Should work - fields",
+			message: message.New(level.Error, "error message").
+				SetFields(fields.Fields{"b": 1, "a": fields.Fields{"c": true}}),
+			want: `level=ERROR msg="error message" component=test a.c=true b=1`,
+		},
+		{
+			name:    "Should not print - below slog level",
+			message: message.New(level.Debug, "debug message"),
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			}))
+
+			o := Slog(logger, level.Trace)
+
+			tt.message.SetComponentName("test")
+
+			if err := o.Write(tt.message); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// SyslogFormat is the syslog message format.
+type SyslogFormat int
+
+const (
+	// SyslogRFC5424 is the RFC 5424 format. Fields are sent as structured
+	// data.
+	SyslogRFC5424 SyslogFormat = iota
+
+	// SyslogRFC3164 is the legacy (BSD) RFC 3164 format. Fields aren't sent.
+	SyslogRFC3164
+)
+
+// SyslogFacility is the syslog facility.
+type SyslogFacility int
+
+// Syslog facilities, as defined in RFC 5424.
+const (
+	SyslogKern SyslogFacility = iota
+	SyslogUser
+	SyslogMail
+	SyslogDaemon
+	SyslogAuth
+	SyslogSyslog
+	SyslogLpr
+	SyslogNews
+	SyslogUucp
+	SyslogCron
+	SyslogAuthPriv
+	SyslogFtp
+	_
+	_
+	_
+	_
+	SyslogLocal0
+	SyslogLocal1
+	SyslogLocal2
+	SyslogLocal3
+	SyslogLocal4
+	SyslogLocal5
+	SyslogLocal6
+	SyslogLocal7
+)
+
+// DefaultSyslogStructuredDataID is the default SD-ID used to send fields as
+// RFC 5424 structured data. 32473 is the private enterprise number reserved
+// for documentation.
+const DefaultSyslogStructuredDataID = "fields@32473"
+
+// Known local syslog sockets.
+var syslogLocalAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogOptions are options for the `SyslogWriter`.
+type SyslogOptions struct {
+	// Network is the network to connect to, e.g.: `unixgram`, `unix`, `udp`,
+	// or `tcp`. If empty, connects to the local syslog daemon, trying known
+	// sockets, e.g.: `/dev/log`.
+	Network string
+
+	// Address to connect to. Ignored if `Network` is empty.
+	Address string
+
+	// Facility. Default is `SyslogUser`. `SyslogKern` is reserved to the
+	// kernel, thus replaced by `SyslogUser`.
+	Facility SyslogFacility
+
+	// Format. Default is `SyslogRFC5424`.
+	Format SyslogFormat
+
+	// AppName, also known as tag. Default is the executable name.
+	AppName string
+
+	// Hostname. Default is `os.Hostname()`.
+	Hostname string
+
+	// MsgID identifies the type of message (RFC 5424 only). Default is none.
+	MsgID string
+
+	// StructuredDataID is the SD-ID used to send fields as structured data
+	// (RFC 5424 only). Default is `DefaultSyslogStructuredDataID`.
+	StructuredDataID string
+}
+
+// SyslogWriter writes messages to a syslog daemon. It's a message-aware
+// writer, so the message's level, and fields are preserved.
+//
+// Notes:
+// - It connects lazily, on the first write.
+// - On connection errors, it reconnects, and retries once.
+// - Messages sent over TCP in the RFC 5424 format are framed using octet
+// counting (RFC 6587), other stream based messages are new line terminated.
+type SyslogWriter struct {
+	// Guards the connection.
+	mu sync.Mutex
+
+	// Current connection, if any.
+	conn net.Conn
+
+	// Whether the connection is to the local syslog daemon.
+	local bool
+
+	// Options.
+	options SyslogOptions
+}
+
+// Write implements the io.Writer interface. Content is written at the
+// `Informational` severity, without fields.
+func (s *SyslogWriter) Write(p []byte) (int, error) {
+	if err := s.send(s.format(
+		s.priority(level.SyslogInformational),
+		time.Now(),
+		strings.TrimRight(string(p), "\r\n"),
+		nil,
+	)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// WriteMessage implements the `IMessageWriter` interface.
+func (s *SyslogWriter) WriteMessage(m message.IMessage) error {
+	return s.send(s.format(
+		s.priority(m.GetLevel().SyslogSeverity()),
+		m.GetTimestamp(),
+		strings.TrimRight(m.GetContent().GetProcessed(), "\r\n"),
+		m.GetFields(),
+	))
+}
+
+// Close closes the connection, if any.
+func (s *SyslogWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+
+	s.conn = nil
+
+	return err
+}
+
+//////
+// Helpers.
+//////
+
+// Returns the PRI value.
+func (s *SyslogWriter) priority(severity int) int {
+	return int(s.options.Facility)*8 + severity
+}
+
+// Formats the message according with the format.
+func (s *SyslogWriter) format(pri int, ts time.Time, content string, f fields.Fields) string {
+	if s.options.Format == SyslogRFC3164 {
+		// Local daemons don't expect the hostname.
+		hostname := s.options.Hostname + " "
+
+		if s.local {
+			hostname = ""
+		}
+
+		return fmt.Sprintf("<%d>%s %s%s[%d]: %s",
+			pri,
+			ts.Format(time.Stamp),
+			hostname,
+			s.options.AppName,
+			os.Getpid(),
+			content,
+		)
+	}
+
+	msgID := s.options.MsgID
+
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		pri,
+		ts.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderValue(s.options.Hostname, 255),
+		syslogHeaderValue(s.options.AppName, 48),
+		os.Getpid(),
+		syslogHeaderValue(msgID, 32),
+		syslogStructuredData(s.options.StructuredDataID, f),
+		content,
+	)
+}
+
+// Frames the message according with the network.
+func (s *SyslogWriter) frame(msg string) string {
+	switch s.conn.(type) {
+	case *net.TCPConn:
+		if s.options.Format == SyslogRFC5424 {
+			return strconv.Itoa(len(msg)) + " " + msg
+		}
+
+		return msg + "\n"
+	case *net.UnixConn:
+		if s.conn.LocalAddr().Network() == "unix" {
+			return msg + "\n"
+		}
+	}
+
+	return msg
+}
+
+// Sends the message, connecting if needed. On failure, it reconnects, and
+// retries once.
+func (s *SyslogWriter) send(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.connect(); err != nil {
+				continue
+			}
+		}
+
+		if _, err = s.conn.Write([]byte(s.frame(msg))); err == nil {
+			return nil
+		}
+
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	return fmt.Errorf("syslog: %w", err)
+}
+
+// Connects to the syslog daemon.
+func (s *SyslogWriter) connect() error {
+	if s.options.Network != "" {
+		conn, err := net.Dial(s.options.Network, s.options.Address)
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+
+		return nil
+	}
+
+	errs := []error{}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, address := range syslogLocalAddresses {
+			conn, err := net.Dial(network, address)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			s.conn = conn
+			s.local = true
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unix syslog delivery error: %w", errors.Join(errs...))
+}
+
+// Returns a RFC 5424 header value: printable US-ASCII, truncated to `maxLen`,
+// or `-` if empty.
+func syslogHeaderValue(v string, maxLen int) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, v)
+
+	if v == "" {
+		return "-"
+	}
+
+	if len(v) > maxLen {
+		v = v[:maxLen]
+	}
+
+	return v
+}
+
+// Returns a RFC 5424 SD-NAME: printable US-ASCII, except `=`, space, `]`, and
+// `"`, truncated to 32 characters.
+func syslogSDName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	return name
+}
+
+// Returns the RFC 5424 structured data element with fields - flattened, and
+// sorted by key, or `-` if there's no fields.
+func syslogStructuredData(id string, f fields.Fields) string {
+	if len(f) == 0 {
+		return "-"
+	}
+
+	flattened := fields.Flatten(f)
+
+	keys := make([]string, 0, len(flattened))
+
+	for k := range flattened {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+	buf := new(strings.Builder)
+
+	buf.WriteString("[" + syslogSDName(id))
+
+	for _, k := range keys {
+		fmt.Fprintf(buf, ` %s="%s"`, syslogSDName(k), escaper.Replace(fmt.Sprint(flattened[k])))
+	}
+
+	buf.WriteString("]")
+
+	return buf.String()
+}
+
+//////
+// Factory.
+//////
+
+// NewSyslogWriter is the `SyslogWriter` factory. `opts` is optional.
+func NewSyslogWriter(opts *SyslogOptions) *SyslogWriter {
+	s := &SyslogWriter{}
+
+	if opts != nil {
+		s.options = *opts
+	}
+
+	if s.options.Facility == SyslogKern {
+		s.options.Facility = SyslogUser
+	}
+
+	if s.options.AppName == "" {
+		s.options.AppName = filepath.Base(os.Args[0])
+	}
+
+	if s.options.Hostname == "" {
+		s.options.Hostname, _ = os.Hostname()
+	}
+
+	if s.options.StructuredDataID == "" {
+		s.options.StructuredDataID = DefaultSyslogStructuredDataID
+	}
+
+	return s
+}
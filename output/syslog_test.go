@@ -0,0 +1,179 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+func TestSyslog(t *testing.T) {
+	ts := time.Date(2021, 6, 22, 12, 51, 46, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		network string
+		opts    SyslogOptions
+		message message.IMessage
+		want    string
+	}{
+		{
+			name:    "Should work - RFC5424",
+			network: "unixgram",
+			opts:    SyslogOptions{MsgID: "id"},
+			message: message.New(level.Info, "info message\n").
+				SetFields(fields.Fields{"b": fields.Fields{"c": `x"y]`}, "a": 1}),
+			want: fmt.Sprintf(`<14>1 2021-06-22T12:51:46.000000Z host app %d id [fields@32473 a="1" b.c="x\"y\]"] info message`, os.Getpid()),
+		},
+		{
+			name:    "Should work - RFC5424 - no fields",
+			network: "unixgram",
+			opts:    SyslogOptions{Facility: SyslogLocal0},
+			message: message.New(level.Warn, "warn message"),
+			want:    fmt.Sprintf(`<132>1 2021-06-22T12:51:46.000000Z host app %d - - warn message`, os.Getpid()),
+		},
+		{
+			name:    "Should work - RFC3164",
+			network: "udp",
+			opts:    SyslogOptions{Format: SyslogRFC3164},
+			message: message.New(level.Error, "error message"),
+			want:    fmt.Sprintf(`<11>Jun 22 12:51:46 host app[%d]: error message`, os.Getpid()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conn net.PacketConn
+			var err error
+
+			if tt.network == "unixgram" {
+				conn, err = net.ListenPacket("unixgram", filepath.Join(t.TempDir(), "log.sock"))
+			} else {
+				conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+			}
+
+			if err != nil {
+				t.Fatalf("Listen failed: %s", err)
+			}
+
+			defer conn.Close()
+
+			tt.opts.Network = tt.network
+			tt.opts.Address = conn.LocalAddr().String()
+			tt.opts.AppName = "app"
+			tt.opts.Hostname = "host"
+
+			o := Syslog(level.Trace, &tt.opts)
+
+			tt.message.SetTimestamp(ts)
+
+			if err := o.Write(tt.message); err != nil {
+				t.Fatalf("Write failed: %s", err)
+			}
+
+			buf := make([]byte, 1024)
+
+			_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("ReadFrom failed: %s", err)
+			}
+
+			if got := string(buf[:n]); got != tt.want {
+				t.Errorf("Got %s, want %s", got, tt.want)
+			}
+
+			if err := o.Close(); err != nil {
+				t.Errorf("Close failed: %s", err)
+			}
+		})
+	}
+}
+
+// Reads octet counting framed messages.
+func readOctetCounted(r *bufio.Reader) (string, error) {
+	length, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, n)
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func TestSyslog_Reconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+
+	defer l.Close()
+
+	received := make(chan string, 100)
+
+	// Closes each connection after the first message.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			if msg, err := readOctetCounted(bufio.NewReader(conn)); err == nil {
+				received <- msg
+			}
+
+			conn.Close()
+		}
+	}()
+
+	o := Syslog(level.Trace, &SyslogOptions{
+		Network:  "tcp",
+		Address:  l.Addr().String(),
+		AppName:  "app",
+		Hostname: "host",
+	})
+
+	defer o.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := o.Write(message.New(level.Info, fmt.Sprintf("message %d", i))); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+
+		// Gives time to the server to close the connection.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(received) < 2 {
+		t.Fatalf("Got %d messages, want messages from multiple connections", len(received))
+	}
+
+	if msg := <-received; !strings.HasSuffix(msg, " - - message 0") {
+		t.Errorf("Got %s, want message 0", msg)
+	}
+}
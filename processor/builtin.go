@@ -12,9 +12,12 @@ import (
 
 	"github.com/acarl005/stripansi"
 	"github.com/saucelabs/sypl/color"
+	"github.com/saucelabs/sypl/content"
+	"github.com/saucelabs/sypl/fields"
 	"github.com/saucelabs/sypl/flag"
 	"github.com/saucelabs/sypl/level"
 	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
 )
 
 // Casing definition, e.g.: Upper, Lower, Title, etc.
@@ -49,6 +52,16 @@ func generateDefaultPrefix(timestamp, component string, level level.Level) strin
 	)
 }
 
+// Returns the trailing line break of the message original content, if any.
+// It's used by messages emitted by processors, e.g.: summaries.
+func trailingLineBreak(m message.IMessage) string {
+	if strings.HasSuffix(m.GetContent().GetOriginal(), "\n") {
+		return "\n"
+	}
+
+	return ""
+}
+
 //////
 // Built-in processors.
 //////
@@ -113,6 +126,27 @@ func Decolourizer() IProcessor {
 	})
 }
 
+// Dedup suppresses repeated messages - consecutive, or within a window, like
+// syslogd. When the sequence ends, or the window fires, a summary - e.g.:
+// "Last message repeated 3 times", is written. Suppressed messages are muted.
+// See `DedupOptions`, which is optional.
+//
+// Notes:
+// - Messages flagged with `Force`, and summaries aren't deduplicated.
+// - Messages the output wouldn't print - e.g.: filtered by level, aren't
+// compared, so they don't break sequences.
+func Dedup(opts *DedupOptions) IProcessor {
+	d := newDeduplicator(opts)
+
+	return NewStateful("Dedup", func(m message.IMessage) error {
+		if !skipDedup(m) && !d.dedup(m) {
+			m.SetFlag(flag.Mute)
+		}
+
+		return nil
+	})
+}
+
 // ErrorSimulator simulates an error in the pipeline.
 //
 //nolint:goerr113
@@ -122,6 +156,24 @@ func ErrorSimulator(msg string) IProcessor {
 	})
 }
 
+// FilterFields controls which fields are written, by key path - see
+// `FieldFilterOptions`. Filtered fields are dropped, masked, or hashed. As
+// processors are per output, it allows, e.g.: a local file to keep all fields,
+// while a network output gets a reduced set.
+//
+// Note: Fields are copied, not changed, as they are shared between outputs.
+func FilterFields(opts *FieldFilterOptions) IProcessor {
+	f := newFieldFilter(opts)
+
+	return New("FilterFields", func(m message.IMessage) error {
+		if len(m.GetFields()) > 0 {
+			m.SetFields(f.filter(m.GetFields(), nil, false))
+		}
+
+		return nil
+	})
+}
+
 // ForceBasedOnLevel force messages to be printed based on the specified levels.
 func ForceBasedOnLevel(levels ...level.Level) IProcessor {
 	return New("ForceBasedOnLevel", func(m message.IMessage) error {
@@ -163,6 +215,28 @@ func PrefixBasedOnMask(timestampFormat string) IProcessor {
 	})
 }
 
+// PrefixBasedOnMaskWithCaller is a specialized version of the
+// `PrefixBasedOnMask`. It also adds the caller, if known.
+//
+// Example: 2021-06-22 12:51:46.089 [80819] [CLI] [Info] [main.go:12].
+func PrefixBasedOnMaskWithCaller(timestampFormat string) IProcessor {
+	return New("PrefixBasedOnMaskWithCaller", func(m message.IMessage) error {
+		prefix := generateDefaultPrefix(
+			m.GetTimestamp().Format(timestampFormat),
+			m.GetComponentName(),
+			m.GetLevel(),
+		)
+
+		if caller := m.GetCaller(); caller.PC != 0 {
+			prefix += fmt.Sprintf("[%s] ", shared.ShortCaller(caller.File, caller.Line))
+		}
+
+		m.GetContent().SetProcessed(prefix + m.GetContent().GetProcessed())
+
+		return nil
+	})
+}
+
 // PrefixBasedOnMaskExceptForLevels is a specialized version of the
 // `PrefixBasedOnMask`. It prefixes all messages, except for the specified
 // levels.
@@ -215,6 +289,94 @@ func PrintOnlyIfTagged(tag string) IProcessor {
 	})
 }
 
+// RateLimiter limits messages per second, and burst, using token buckets.
+// Levels can have their own budget. Limited messages are muted. Messages
+// flagged with `Force` are limited too, unless configured otherwise. See
+// `RateLimiterOptions`, which is optional. Use the returned limiter to get
+// how many messages were limited.
+//
+// Notes:
+// - Processors are per output, so are limits.
+// - Messages the output wouldn't print - e.g.: filtered by level, don't take
+// tokens.
+func RateLimiter(opts *RateLimiterOptions) (*Limiter, IProcessor) {
+	l := newLimiter(opts)
+
+	return l, NewStateful("RateLimiter", func(m message.IMessage) error {
+		if !l.allow(m) {
+			m.SetFlag(flag.Mute)
+		}
+
+		return nil
+	})
+}
+
+// Redact redacts secrets, and PII - e.g.: bearer tokens, AWS keys, JWTs,
+// emails, credit card numbers, and IPs, from the message content, and from
+// string values of fields, including nested ones. Secrets are replaced
+// according to the strategy - see `RedactStrategy`. The number of redactions
+// is stored in a field - see `RedactOptions`, which is optional.
+//
+// Notes:
+// - The original content is also redacted, so secrets don't leak thru it,
+// e.g.: to fallback outputs.
+// - Fields are copied, not changed, as they are shared between outputs.
+func Redact(opts *RedactOptions) IProcessor {
+	r := newRedactor(opts)
+
+	return New("Redact", func(m message.IMessage) error {
+		original, _ := r.redact(m.GetContent().GetOriginal())
+		processed, total := r.redact(m.GetContent().GetProcessed())
+
+		// The original content can't be changed, only replaced.
+		c := content.New(original)
+		c.SetProcessed(processed)
+
+		m.SetContent(c)
+
+		var f fields.Fields
+
+		if len(m.GetFields()) > 0 {
+			redacted, count := r.redactMap(m.GetFields())
+
+			f = redacted
+			total += count
+		}
+
+		if total > 0 {
+			if f == nil {
+				f = fields.Fields{}
+			}
+
+			f[r.options.CountField] = total
+
+			m.SetFields(f)
+		}
+
+		return nil
+	})
+}
+
+// Sampler samples messages, per key: each interval, the first N messages are
+// written, then every Mth. Suppressed messages are muted, and summarized at
+// the end of the interval. See `SamplerOptions`, which is optional.
+//
+// Notes:
+// - Messages flagged with `Force`, and summaries aren't sampled.
+// - Messages the output wouldn't print - e.g.: filtered by level, aren't
+// counted.
+func Sampler(opts *SamplerOptions) IProcessor {
+	s := newSampler(opts)
+
+	return NewStateful("Sampler", func(m message.IMessage) error {
+		if !skipSampling(m) && !s.sample(m) {
+			m.SetFlag(flag.Mute)
+		}
+
+		return nil
+	})
+}
+
 // Suffixer suffixes a message with the specified `suffix`.
 func Suffixer(suffix string) IProcessor {
 	return New("Suffixer", func(m message.IMessage) error {
@@ -0,0 +1,282 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/flag"
+	"github.com/saucelabs/sypl/message"
+)
+
+// DefaultDedupWindow is the default window of the `Dedup` processor.
+const DefaultDedupWindow = 30 * time.Second
+
+// DedupSummaryTag tags summaries emitted by the `Dedup` processor. Tagged
+// messages aren't deduplicated.
+const DedupSummaryTag = "dedup_summary"
+
+// DedupMode defines which messages are compared.
+type DedupMode int
+
+const (
+	// DedupConsecutive suppresses messages identical to the previous one.
+	DedupConsecutive DedupMode = iota
+
+	// DedupWindow suppresses messages identical to any other within the
+	// window.
+	DedupWindow
+)
+
+// DedupEquality defines when messages are identical.
+type DedupEquality int
+
+const (
+	// DedupContent compares the original content.
+	DedupContent DedupEquality = iota
+
+	// DedupContentAndLevel compares the original content, and the level.
+	DedupContentAndLevel
+
+	// DedupContentAndFields compares the original content, and fields - see
+	// `DedupOptions`.
+	DedupContentAndFields
+)
+
+// DedupOptions are options for the `Dedup` processor. Zero values fallback to
+// the defaults.
+type DedupOptions struct {
+	// Equality. Default is `DedupContent`.
+	Equality DedupEquality
+
+	// Fields compared by `DedupContentAndFields`. Default is all fields.
+	Fields []string
+
+	// Mode. Default is `DedupConsecutive`.
+	Mode DedupMode
+
+	// Window of the `DedupWindow` mode. In the `DedupConsecutive` mode,
+	// repeats are summarized at least once per window. Default is
+	// `DefaultDedupWindow`.
+	Window time.Duration
+
+	// Writer, if set, summaries - e.g.: "Last message repeated 3 times", are
+	// written to. Usually, the output the processor is added to.
+	Writer Writer
+}
+
+// Repeats of a message.
+type dedupEntry struct {
+	// Equality key.
+	key string
+
+	// First occurrence, used to build the summary.
+	first message.IMessage
+
+	// Number of suppressed repeats.
+	repeats int
+}
+
+// Suppresses repeated messages.
+type deduplicator struct {
+	options DedupOptions
+
+	// Guards entries, and the timer.
+	mu sync.Mutex
+
+	// Last message, in the `DedupConsecutive` mode.
+	last *dedupEntry
+
+	// Messages of the current window, in the `DedupWindow` mode.
+	entries map[string]*dedupEntry
+
+	// Fires at the end of the current window, if any.
+	timer *time.Timer
+}
+
+// Returns false if the message is a repeat, and should be suppressed.
+func (d *deduplicator) dedup(m message.IMessage) bool {
+	key := d.key(m)
+
+	d.mu.Lock()
+
+	var summaries []message.IMessage
+
+	unique := true
+
+	switch d.options.Mode {
+	case DedupWindow:
+		if e, ok := d.entries[key]; ok {
+			e.repeats++
+
+			unique = false
+		} else {
+			d.entries[key] = &dedupEntry{key: key, first: m}
+		}
+
+		d.startTimer()
+	default:
+		if d.last != nil && d.last.key == key {
+			d.last.repeats++
+
+			unique = false
+
+			d.startTimer()
+		} else {
+			// The sequence ended.
+			summaries = d.summarize()
+
+			d.last = &dedupEntry{key: key, first: m}
+		}
+	}
+
+	d.mu.Unlock()
+
+	d.write(summaries)
+
+	return unique
+}
+
+// Returns the equality key of the message.
+func (d *deduplicator) key(m message.IMessage) string {
+	switch d.options.Equality {
+	case DedupContentAndLevel:
+		return m.GetLevel().String() + "\x00" + m.GetContent().GetOriginal()
+	case DedupContentAndFields:
+		keys := d.options.Fields
+
+		if len(keys) == 0 {
+			for k := range m.GetFields() {
+				keys = append(keys, k)
+			}
+
+			sort.Strings(keys)
+		}
+
+		var b strings.Builder
+
+		b.WriteString(m.GetContent().GetOriginal())
+
+		for _, k := range keys {
+			if v, ok := m.GetFields()[k]; ok {
+				fmt.Fprintf(&b, "\x00%s=%v", k, v)
+			}
+		}
+
+		return b.String()
+	default:
+		return m.GetContent().GetOriginal()
+	}
+}
+
+// Starts the window, if not yet.
+//
+// Note: Must be called with the lock held.
+func (d *deduplicator) startTimer() {
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.options.Window, d.tick)
+	}
+}
+
+// Ends the current window, writing summaries.
+func (d *deduplicator) tick() {
+	d.mu.Lock()
+
+	d.timer = nil
+
+	summaries := d.summarize()
+
+	d.entries = map[string]*dedupEntry{}
+
+	d.mu.Unlock()
+
+	d.write(summaries)
+}
+
+// Returns summaries of repeats, in order, resetting their count.
+//
+// Note: Must be called with the lock held.
+func (d *deduplicator) summarize() []message.IMessage {
+	summaries := []message.IMessage{}
+
+	if d.last != nil && d.last.repeats > 0 {
+		summaries = append(summaries, newDedupSummary(d.last,
+			fmt.Sprintf("Last message repeated %d times", d.last.repeats),
+		))
+
+		d.last.repeats = 0
+	}
+
+	repeated := []*dedupEntry{}
+
+	for _, e := range d.entries {
+		if e.repeats > 0 {
+			repeated = append(repeated, e)
+		}
+	}
+
+	sort.Slice(repeated, func(i, j int) bool {
+		return repeated[i].first.GetTimestamp().Before(repeated[j].first.GetTimestamp())
+	})
+
+	for _, e := range repeated {
+		summaries = append(summaries, newDedupSummary(e,
+			fmt.Sprintf("Message repeated %d times: %s", e.repeats, strings.TrimSuffix(e.first.GetContent().GetOriginal(), "\n")),
+		))
+
+		e.repeats = 0
+	}
+
+	return summaries
+}
+
+// Writes summaries, if there's a writer.
+func (d *deduplicator) write(summaries []message.IMessage) {
+	if d.options.Writer == nil {
+		return
+	}
+
+	for _, m := range summaries {
+		// Errors are already reported by the writer.
+		_ = d.options.Writer.Write(m)
+	}
+}
+
+// Returns the summary of repeats.
+func newDedupSummary(e *dedupEntry, content string) message.IMessage {
+	m := message.New(e.first.GetLevel(), content+trailingLineBreak(e.first))
+
+	m.SetComponentName(e.first.GetComponentName())
+	m.SetOutputName(e.first.GetOutputName())
+	m.SetFields(fields.Fields{"repeated": e.repeats})
+	m.AddTags(DedupSummaryTag)
+
+	return m
+}
+
+// Returns true if the message shouldn't be deduplicated.
+func skipDedup(m message.IMessage) bool {
+	return m.GetFlag() == flag.Force || m.ContainTag(DedupSummaryTag)
+}
+
+// Creates a deduplicator, applying the defaults.
+func newDeduplicator(opts *DedupOptions) *deduplicator {
+	d := &deduplicator{entries: map[string]*dedupEntry{}}
+
+	if opts != nil {
+		d.options = *opts
+	}
+
+	if d.options.Window == 0 {
+		d.options.Window = DefaultDedupWindow
+	}
+
+	return d
+}
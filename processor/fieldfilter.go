@@ -0,0 +1,194 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package processor
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/saucelabs/sypl/fields"
+)
+
+// FieldAction defines what happens to filtered fields.
+type FieldAction int
+
+const (
+	// FieldDrop removes the field.
+	FieldDrop FieldAction = iota
+
+	// FieldMask replaces the value with a fixed placeholder.
+	FieldMask
+
+	// FieldHash replaces the value with its keyed hash (HMAC-SHA256), so
+	// occurrences of the same value stay correlatable.
+	FieldHash
+)
+
+// FieldFilterOptions are options for the `FilterFields` processor. Zero values
+// fallback to the defaults.
+//
+// Key paths are dotted, e.g.: `user.id`, and nested fields - `Fields`, or
+// `map[string]interface{}`, are walked. Patterns are matched per segment, see
+// `path.Match`, e.g.: `user.*.password`. `**` matches any number of segments,
+// e.g.: `**.token`.
+type FieldFilterOptions struct {
+	// Action applied to filtered fields. Default is `FieldDrop`.
+	Action FieldAction
+
+	// Allow, if set, filters fields not matching any of the key paths. Nested
+	// fields of an allowed key path are allowed.
+	Allow []string
+
+	// Deny filters fields matching any of the key paths. It has precedence
+	// over `Allow`.
+	Deny []string
+
+	// HashKey is the key used by the `FieldHash` action. Default is a random
+	// key, thus hashes are only correlatable within the process.
+	HashKey []byte
+
+	// Placeholder used by the `FieldMask` action. Default is
+	// `DefaultRedactPlaceholder`.
+	Placeholder string
+}
+
+// Filters fields by key path.
+type fieldFilter struct {
+	options FieldFilterOptions
+
+	// Split patterns.
+	allow [][]string
+	deny  [][]string
+}
+
+// Returns a filtered copy of the map. `allowed` is true if the map key path is
+// allowed.
+func (f *fieldFilter) filter(src map[string]interface{}, parent []string, allowed bool) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+
+	for k, v := range src {
+		keyPath := append(append([]string{}, parent...), strings.Split(k, ".")...)
+
+		keyAllowed := allowed || len(f.allow) == 0 || matchKeyPath(f.allow, keyPath)
+
+		if !matchKeyPath(f.deny, keyPath) {
+			if nested, ok := toMap(v); ok {
+				filtered := f.filter(nested, keyPath, keyAllowed)
+
+				// Should drop maps which were emptied.
+				if len(filtered) > 0 || (len(nested) == 0 && keyAllowed) {
+					if _, ok := v.(fields.Fields); ok {
+						dst[k] = fields.Fields(filtered)
+					} else {
+						dst[k] = filtered
+					}
+				}
+
+				continue
+			}
+
+			if keyAllowed {
+				dst[k] = v
+
+				continue
+			}
+		}
+
+		switch f.options.Action {
+		case FieldMask:
+			dst[k] = f.options.Placeholder
+		case FieldHash:
+			dst[k] = keyedHash(f.options.HashKey, fmt.Sprint(v))
+		}
+	}
+
+	return dst
+}
+
+// Returns true if the key path matches any of the patterns.
+func matchKeyPath(patterns [][]string, keyPath []string) bool {
+	for _, pattern := range patterns {
+		if matchSegments(pattern, keyPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if the key path matches the pattern, segment by segment. `*`
+// matches a segment, and `**` any number of them.
+func matchSegments(pattern, keyPath []string) bool {
+	if len(pattern) == 0 {
+		return len(keyPath) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(keyPath); i++ {
+			if matchSegments(pattern[1:], keyPath[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(keyPath) == 0 {
+		return false
+	}
+
+	// Invalid patterns don't match.
+	if ok, err := path.Match(pattern[0], keyPath[0]); err != nil || !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], keyPath[1:])
+}
+
+// Returns `v` as a map, if it's a nested field.
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch value := v.(type) {
+	case fields.Fields:
+		return value, true
+	case map[string]interface{}:
+		return value, true
+	}
+
+	return nil, false
+}
+
+// Splits key paths, e.g.: "user.email", into segments.
+func splitKeyPaths(keyPaths []string) [][]string {
+	patterns := make([][]string, 0, len(keyPaths))
+
+	for _, keyPath := range keyPaths {
+		patterns = append(patterns, strings.Split(keyPath, "."))
+	}
+
+	return patterns
+}
+
+// Creates a field filter, applying the defaults, e.g.: a random hash key.
+func newFieldFilter(opts *FieldFilterOptions) *fieldFilter {
+	f := &fieldFilter{}
+
+	if opts != nil {
+		f.options = *opts
+	}
+
+	if f.options.Placeholder == "" {
+		f.options.Placeholder = DefaultRedactPlaceholder
+	}
+
+	if len(f.options.HashKey) == 0 {
+		f.options.HashKey = randomHashKey()
+	}
+
+	f.allow = splitKeyPaths(f.options.Allow)
+	f.deny = splitKeyPaths(f.options.Deny)
+
+	return f
+}
@@ -19,3 +19,21 @@ type IProcessor interface {
 	// Run the processor, if enabled.
 	Run(m message.IMessage) error
 }
+
+// IStatefulProcessor is implemented by processors which keep state across
+// messages, e.g.: `RateLimiter`. Outputs only run stateful processors for
+// messages which would be printed, so messages filtered out - e.g.: by level,
+// don't affect their state.
+type IStatefulProcessor interface {
+	IProcessor
+
+	// IsStateful returns true if the processor keeps state across messages.
+	IsStateful() bool
+}
+
+// Writer writes messages, e.g.: an output. It's used by processors which emit
+// messages, e.g.: `Sampler` summaries.
+type Writer interface {
+	// Write writes the message.
+	Write(m message.IMessage) error
+}
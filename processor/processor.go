@@ -20,6 +20,9 @@ type processor struct {
 	// Name of the processor.
 	name string
 
+	// Whether the processor keeps state across messages.
+	stateful bool
+
 	// Status of the processor.
 	status status.Status
 }
@@ -66,6 +69,15 @@ func (p *processor) Run(m message.IMessage) error {
 	return p.f(m)
 }
 
+//////
+// IStatefulProcessor interface implementation.
+//////
+
+// IsStateful returns true if the processor keeps state across messages.
+func (p *processor) IsStateful() bool {
+	return p.stateful
+}
+
 //////
 // Factory.
 //////
@@ -78,3 +90,14 @@ func New(name string, f RunFunc) IProcessor {
 		status: status.Enabled,
 	}
 }
+
+// NewStateful is the factory of processors which keep state across messages,
+// e.g.: `RateLimiter`. See `IStatefulProcessor`.
+func NewStateful(name string, f RunFunc) IProcessor {
+	return &processor{
+		f:        f,
+		name:     name,
+		stateful: true,
+		status:   status.Enabled,
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package processor
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/sypl/flag"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+)
+
+// DefaultRateLimiterRate is the default number of messages per second allowed
+// by the `RateLimiter`.
+const DefaultRateLimiterRate = 100
+
+// RateLimit is a token bucket budget.
+type RateLimit struct {
+	// Burst is the max number of messages allowed at once. Default is the
+	// rate, at least 1.
+	Burst int
+
+	// Rate is the number of messages per second. Default is
+	// `DefaultRateLimiterRate`. Negative means no limit.
+	Rate float64
+}
+
+// RateLimiterOptions are options for the `RateLimiter` processor. Zero values
+// fallback to the defaults.
+type RateLimiterOptions struct {
+	// Budget shared by levels without their own.
+	RateLimit
+
+	// BypassForce, if true, messages flagged with `Force` aren't limited.
+	BypassForce bool
+
+	// Levels budgets. Levels with their own budget aren't affected by others,
+	// e.g.: errors aren't starved by debug messages.
+	Levels map[level.Level]RateLimit
+}
+
+// A token bucket.
+type tokenBucket struct {
+	burst  float64
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// Returns true, and takes a token, if available.
+func (b *tokenBucket) take(now time.Time) bool {
+	if b.rate < 0 {
+		return true
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Limiter limits messages with token buckets, see `RateLimiter`.
+type Limiter struct {
+	// Options.
+	options RateLimiterOptions
+
+	// Guards buckets, and counters.
+	mu sync.Mutex
+
+	// Shared bucket.
+	bucket *tokenBucket
+
+	// Levels buckets.
+	levels map[level.Level]*tokenBucket
+
+	// Number of limited messages, per level.
+	limited map[level.Level]uint64
+
+	// Returns the current time.
+	now func() time.Time
+}
+
+// GetLimited returns the number of limited messages.
+func (l *Limiter) GetLimited() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	total := uint64(0)
+
+	for _, n := range l.limited {
+		total += n
+	}
+
+	return total
+}
+
+// GetLimitedByLevel returns the number of limited messages at the level.
+func (l *Limiter) GetLimitedByLevel(lvl level.Level) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limited[lvl]
+}
+
+//////
+// Helpers.
+//////
+
+// Returns true if the message is allowed.
+func (l *Limiter) allow(m message.IMessage) bool {
+	if l.options.BypassForce && m.GetFlag() == flag.Force {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.levels[m.GetLevel()]
+
+	if !ok {
+		bucket = l.bucket
+	}
+
+	if bucket.take(l.now()) {
+		return true
+	}
+
+	l.limited[m.GetLevel()]++
+
+	return false
+}
+
+// Creates a token bucket, full, applying the defaults.
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	if limit.Rate == 0 {
+		limit.Rate = DefaultRateLimiterRate
+	}
+
+	if limit.Burst <= 0 {
+		limit.Burst = int(math.Max(1, limit.Rate))
+	}
+
+	return &tokenBucket{
+		burst:  float64(limit.Burst),
+		rate:   limit.Rate,
+		tokens: float64(limit.Burst),
+		last:   now,
+	}
+}
+
+// Creates a limiter, with its shared, and levels buckets.
+func newLimiter(opts *RateLimiterOptions) *Limiter {
+	l := &Limiter{
+		levels:  map[level.Level]*tokenBucket{},
+		limited: map[level.Level]uint64{},
+		now:     time.Now,
+	}
+
+	if opts != nil {
+		l.options = *opts
+	}
+
+	now := l.now()
+
+	l.bucket = newTokenBucket(l.options.RateLimit, now)
+
+	for lvl, limit := range l.options.Levels {
+		l.levels[lvl] = newTokenBucket(limit, now)
+	}
+
+	return l
+}
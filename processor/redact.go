@@ -0,0 +1,347 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/saucelabs/sypl/fields"
+)
+
+// Redact defaults.
+const (
+	DefaultRedactCountField  = "redactions"
+	DefaultRedactPlaceholder = "[REDACTED]"
+)
+
+// RedactStrategy defines how detected secrets are replaced.
+type RedactStrategy int
+
+const (
+	// RedactMask replaces every char of the secret with `*`.
+	RedactMask RedactStrategy = iota
+
+	// RedactPartial replaces every char of the secret with `*`, except for the
+	// last four. Short secrets are fully masked.
+	RedactPartial
+
+	// RedactPlaceholder replaces the secret with a fixed placeholder.
+	RedactPlaceholder
+
+	// RedactHash replaces the secret with its keyed hash (HMAC-SHA256), so
+	// occurrences of the same secret stay correlatable.
+	RedactHash
+)
+
+// Detector detects secrets. If the pattern has capturing groups, only the
+// first one is redacted, e.g.: the token of a bearer authorization.
+type Detector struct {
+	// Name of the detector.
+	Name string
+
+	// Pattern matching secrets.
+	Pattern *regexp.Regexp
+
+	// Validate, if set, discards matches which aren't secrets.
+	Validate func(secret string) bool
+}
+
+// Built-in detectors.
+var (
+	// DetectAWSAccessKeyID detects AWS access key IDs.
+	DetectAWSAccessKeyID = Detector{
+		Name:    "aws_access_key_id",
+		Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16})\b`),
+	}
+
+	// DetectAWSSecretAccessKey detects AWS secret access keys, when assigned,
+	// e.g.: `aws_secret_access_key=...`.
+	DetectAWSSecretAccessKey = Detector{
+		Name:    "aws_secret_access_key",
+		Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`),
+	}
+
+	// DetectBearer detects bearer tokens, e.g.: `Authorization: Bearer ...`.
+	DetectBearer = Detector{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+	}
+
+	// DetectCreditCard detects credit card numbers, optionally separated by
+	// spaces, or dashes. Numbers are validated with the Luhn algorithm.
+	DetectCreditCard = Detector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Validate: luhn,
+	}
+
+	// DetectEmail detects email addresses.
+	DetectEmail = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`),
+	}
+
+	// DetectIP detects IPv4, and IPv6 addresses.
+	DetectIP = Detector{
+		Name:    "ip",
+		Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`),
+		Validate: func(secret string) bool {
+			return net.ParseIP(secret) != nil
+		},
+	}
+
+	// DetectJWT detects JSON Web Tokens.
+	DetectJWT = Detector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	}
+)
+
+// DefaultDetectors returns the built-in detectors.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		DetectBearer,
+		DetectJWT,
+		DetectAWSAccessKeyID,
+		DetectAWSSecretAccessKey,
+		DetectEmail,
+		DetectCreditCard,
+		DetectIP,
+	}
+}
+
+// RedactOptions are options for the `Redact` processor. Zero values fallback
+// to the defaults.
+type RedactOptions struct {
+	// CountField is the field the number of redactions is stored in. Default
+	// is `DefaultRedactCountField`.
+	CountField string
+
+	// Detectors in use. Default is `DefaultDetectors`. Custom detectors can be
+	// appended to them.
+	Detectors []Detector
+
+	// HashKey is the key used by the `RedactHash` strategy. Default is a
+	// random key, thus hashes are only correlatable within the process.
+	HashKey []byte
+
+	// Placeholder used by the `RedactPlaceholder` strategy. Default is
+	// `DefaultRedactPlaceholder`.
+	Placeholder string
+
+	// Strategy. Default is `RedactMask`.
+	Strategy RedactStrategy
+}
+
+// Redacts secrets.
+type redactor struct {
+	options RedactOptions
+}
+
+// Redacts secrets in `s`, returning the number of redactions.
+func (r *redactor) redact(s string) (string, int) {
+	count := 0
+
+	for _, d := range r.options.Detectors {
+		indexes := d.Pattern.FindAllStringSubmatchIndex(s, -1)
+
+		if len(indexes) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+
+		last := 0
+
+		for _, index := range indexes {
+			start, end := index[0], index[1]
+
+			// Should only redact the first capturing group, if any.
+			if len(index) >= 4 && index[2] >= 0 {
+				start, end = index[2], index[3]
+			}
+
+			secret := s[start:end]
+
+			if secret == "" || (d.Validate != nil && !d.Validate(secret)) {
+				continue
+			}
+
+			b.WriteString(s[last:start])
+			b.WriteString(r.replace(secret))
+
+			last = end
+			count++
+		}
+
+		b.WriteString(s[last:])
+
+		s = b.String()
+	}
+
+	return s, count
+}
+
+// Redacts secrets in string values, recursively, returning the number of
+// redactions. Maps, and slices are copied, never changed.
+func (r *redactor) redactValue(v interface{}) (interface{}, int) {
+	switch value := v.(type) {
+	case string:
+		return r.redact(value)
+	case fields.Fields:
+		f, count := r.redactMap(value)
+
+		return fields.Fields(f), count
+	case map[string]interface{}:
+		return r.redactMap(value)
+	case []string:
+		values := make([]string, len(value))
+		total := 0
+
+		for i, e := range value {
+			redacted, count := r.redact(e)
+
+			values[i] = redacted
+			total += count
+		}
+
+		return values, total
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		total := 0
+
+		for i, e := range value {
+			redacted, count := r.redactValue(e)
+
+			values[i] = redacted
+			total += count
+		}
+
+		return values, total
+	}
+
+	return v, 0
+}
+
+// Returns a copy of the map, with secrets redacted.
+func (r *redactor) redactMap(m map[string]interface{}) (map[string]interface{}, int) {
+	redacted := make(map[string]interface{}, len(m))
+	total := 0
+
+	for k, v := range m {
+		value, count := r.redactValue(v)
+
+		redacted[k] = value
+		total += count
+	}
+
+	return redacted, total
+}
+
+// Replaces the secret according to the strategy.
+func (r *redactor) replace(secret string) string {
+	switch r.options.Strategy {
+	case RedactPartial:
+		runes := []rune(secret)
+
+		if len(runes) <= 4 {
+			return strings.Repeat("*", len(runes))
+		}
+
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case RedactPlaceholder:
+		return r.options.Placeholder
+	case RedactHash:
+		return keyedHash(r.options.HashKey, secret)
+	default:
+		return strings.Repeat("*", len([]rune(secret)))
+	}
+}
+
+// Returns the truncated, keyed hash (HMAC-SHA256) of `s`.
+func keyedHash(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+
+	// Writing to a hash doesn't fail.
+	_, _ = mac.Write([]byte(s))
+
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// Returns a random key for `keyedHash`.
+func randomHashKey() []byte {
+	key := make([]byte, sha256.Size)
+
+	// Reading from `crypto/rand` doesn't fail.
+	_, _ = rand.Read(key)
+
+	return key
+}
+
+// Validates the number with the Luhn algorithm. Spaces, and dashes are ignored.
+func luhn(number string) bool {
+	sum := 0
+	digits := 0
+
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+
+		if digits%2 == 1 {
+			d *= 2
+
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		digits++
+	}
+
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// Creates a redactor, applying the defaults, e.g.: the default detectors.
+func newRedactor(opts *RedactOptions) *redactor {
+	r := &redactor{}
+
+	if opts != nil {
+		r.options = *opts
+	}
+
+	if r.options.CountField == "" {
+		r.options.CountField = DefaultRedactCountField
+	}
+
+	if r.options.Detectors == nil {
+		r.options.Detectors = DefaultDetectors()
+	}
+
+	if r.options.Placeholder == "" {
+		r.options.Placeholder = DefaultRedactPlaceholder
+	}
+
+	if len(r.options.HashKey) == 0 {
+		r.options.HashKey = randomHashKey()
+	}
+
+	return r
+}
@@ -0,0 +1,200 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package processor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/flag"
+	"github.com/saucelabs/sypl/message"
+)
+
+// Sampler defaults.
+const (
+	DefaultSamplerFirst      = 100
+	DefaultSamplerInterval   = time.Second
+	DefaultSamplerThereafter = 100
+)
+
+// SamplerSummaryTag tags summaries emitted by the `Sampler`. Tagged messages
+// aren't sampled.
+const SamplerSummaryTag = "sampler_summary"
+
+// SamplerKeyFunc returns the key messages are sampled by.
+type SamplerKeyFunc func(m message.IMessage) string
+
+// SampleByContent samples messages by level, and original content.
+func SampleByContent(m message.IMessage) string {
+	return m.GetLevel().String() + ":" + m.GetContent().GetOriginal()
+}
+
+// SampleByField samples messages by the value of the field `key`. Messages
+// without the field are sampled by content, see `SampleByContent`.
+func SampleByField(key string) SamplerKeyFunc {
+	return func(m message.IMessage) string {
+		if v, ok := m.GetFields()[key]; ok {
+			return fmt.Sprintf("%s=%v", key, v)
+		}
+
+		return SampleByContent(m)
+	}
+}
+
+// SamplerOptions are options for the `Sampler` processor. Zero values
+// fallback to the defaults.
+type SamplerOptions struct {
+	// First is the number of messages, per key, written each interval. Default
+	// is `DefaultSamplerFirst`.
+	First int
+
+	// Interval of sampling. Counters are reset each interval. Default is
+	// `DefaultSamplerInterval`.
+	Interval time.Duration
+
+	// Key returns the key messages are sampled by. Default is
+	// `SampleByContent`.
+	Key SamplerKeyFunc
+
+	// Thereafter, every Mth message, per key, is written, after the first
+	// ones. Default is `DefaultSamplerThereafter`. Negative means none.
+	Thereafter int
+
+	// Writer, if set, summaries are written to, at the end of intervals with
+	// suppressed messages, one per key. Usually, the output the sampler is
+	// added to.
+	Writer Writer
+}
+
+// Counts messages of a key, in the current interval.
+type samplerCounter struct {
+	// Number of messages.
+	count int
+
+	// Number of suppressed messages.
+	suppressed int
+
+	// First message, used to build the summary.
+	first message.IMessage
+}
+
+// Samples messages.
+type sampler struct {
+	options SamplerOptions
+
+	// Guards counters, and the timer.
+	mu sync.Mutex
+
+	// Counters, per key, of the current interval.
+	counters map[string]*samplerCounter
+
+	// Fires at the end of the current interval, if any.
+	timer *time.Timer
+}
+
+// Returns true if the message should be written.
+func (s *sampler) sample(m message.IMessage) bool {
+	key := s.options.Key(m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Should start an interval, if none.
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.options.Interval, s.tick)
+	}
+
+	c, ok := s.counters[key]
+
+	if !ok {
+		c = &samplerCounter{first: m}
+
+		s.counters[key] = c
+	}
+
+	c.count++
+
+	if c.count <= s.options.First ||
+		(s.options.Thereafter > 0 && (c.count-s.options.First)%s.options.Thereafter == 0) {
+		return true
+	}
+
+	c.suppressed++
+
+	return false
+}
+
+// Ends the current interval, resetting counters, and writing summaries.
+func (s *sampler) tick() {
+	s.mu.Lock()
+
+	counters := s.counters
+
+	s.counters = map[string]*samplerCounter{}
+	s.timer = nil
+
+	s.mu.Unlock()
+
+	if s.options.Writer == nil {
+		return
+	}
+
+	for key, c := range counters {
+		if c.suppressed > 0 {
+			// Errors are already reported by the writer.
+			_ = s.options.Writer.Write(newSamplerSummary(key, c))
+		}
+	}
+}
+
+// Returns the summary of suppressed messages of a key.
+func newSamplerSummary(key string, c *samplerCounter) message.IMessage {
+	m := message.New(c.first.GetLevel(), fmt.Sprintf(
+		"Sampler suppressed %d messages like: %s",
+		c.suppressed,
+		c.first.GetContent().GetOriginal(),
+	))
+
+	m.SetComponentName(c.first.GetComponentName())
+	m.SetOutputName(c.first.GetOutputName())
+	m.SetFields(fields.Fields{"sampler_key": key, "suppressed": c.suppressed})
+	m.AddTags(SamplerSummaryTag)
+
+	return m
+}
+
+// Returns true if the message shouldn't be sampled.
+func skipSampling(m message.IMessage) bool {
+	return m.GetFlag() == flag.Force || m.ContainTag(SamplerSummaryTag)
+}
+
+// Creates a sampler, applying the defaults.
+func newSampler(opts *SamplerOptions) *sampler {
+	s := &sampler{counters: map[string]*samplerCounter{}}
+
+	if opts != nil {
+		s.options = *opts
+	}
+
+	if s.options.First == 0 {
+		s.options.First = DefaultSamplerFirst
+	}
+
+	if s.options.Interval == 0 {
+		s.options.Interval = DefaultSamplerInterval
+	}
+
+	if s.options.Key == nil {
+		s.options.Key = SampleByContent
+	}
+
+	if s.options.Thereafter == 0 {
+		s.options.Thereafter = DefaultSamplerThereafter
+	}
+
+	return s
+}
@@ -3,7 +3,9 @@ package shared
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 )
 
 // Prettify encodes data returning its JSON-stringified version.
@@ -23,3 +25,13 @@ func Prettify(data interface{}) string {
 
 	return buf.String()
 }
+
+// ShortCaller returns the final file name element, and the line number of a
+// caller, e.g.: `sypl.go:23`.
+func ShortCaller(file string, line int) string {
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		file = file[i+1:]
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/shared"
+	"github.com/saucelabs/sypl/status"
+)
+
+// SlogHandlerOptions are options for the `SlogHandler`.
+type SlogHandlerOptions struct {
+	// AddSource adds a `source` field with the file, and line of the `slog`
+	// call.
+	AddSource bool
+}
+
+// SlogHandler is a `slog.Handler` backed by a Sypl logger. It converts
+// `slog.Record`s into messages, and pushes them thru the logger's outputs,
+// and processors. Attributes are converted into structured fields, and groups
+// into nested fields.
+type SlogHandler struct {
+	// Accumulated attributes (`WithAttrs`), already nested by groups.
+	fields fields.Fields
+
+	// Opened groups (`WithGroup`).
+	groups []string
+
+	// Handler's options.
+	options SlogHandlerOptions
+
+	// Logger backing the handler.
+	sypl *Sypl
+}
+
+//////
+// slog.Handler interface implementation.
+//////
+
+// Enabled reports whether the handler handles records at the given level. It
+// does if any enabled output would print it.
+//
+// Note: If the debug env var is set, the final decision is up to the outputs.
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	if os.Getenv(shared.DebugEnvVar) != "" {
+		return true
+	}
+
+	lvl := level.FromSlog(l)
+
+	for _, o := range h.sypl.GetOutputs() {
+		if o.GetStatus() == status.Enabled &&
+			lvl >= o.GetMinLevel() &&
+			lvl <= o.GetMaxLevel() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handle converts the record into a message, and process it.
+//
+// Note: Fields extracted from the context by the logger's extractors are
+// added, attributes have precedence.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	f := h.sypl.extractFields(ctx)
+	fields.Copy(copyFields(h.fields), f)
+
+	r.Attrs(func(a slog.Attr) bool {
+		addAttrs(f, h.groups, a)
+
+		return true
+	})
+
+	m := message.New(level.FromSlog(r.Level), r.Message)
+	m.SetFields(f)
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+
+		m.SetCaller(frame)
+
+		if h.options.AddSource {
+			f["source"] = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+	}
+
+	if !r.Time.IsZero() {
+		m.SetTimestamp(r.Time)
+	}
+
+	h.sypl.process(m)
+
+	return nil
+}
+
+// WithAttrs returns a new handler whose fields consists of both the handler's
+// fields, and the specified attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+
+	addAttrs(h2.fields, h2.groups, attrs...)
+
+	return h2
+}
+
+// WithGroup returns a new handler with the given group appended to the
+// handler's existing groups. Subsequent attributes are nested under it.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+
+	return h2
+}
+
+//////
+// Helpers.
+//////
+
+// Clones the handler, deep-copying its fields, and groups.
+func (h *SlogHandler) clone() *SlogHandler {
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+
+	return &SlogHandler{
+		fields:  copyFields(h.fields),
+		groups:  groups,
+		options: h.options,
+		sypl:    h.sypl,
+	}
+}
+
+// Deep-copies fields. Nested fields are also copied.
+func copyFields(src fields.Fields) fields.Fields {
+	dst := make(fields.Fields, len(src))
+
+	for k, v := range src {
+		if nested, ok := v.(fields.Fields); ok {
+			v = copyFields(nested)
+		}
+
+		dst[k] = v
+	}
+
+	return dst
+}
+
+// Adds attributes to `dst`, nested under the specified groups. Empty
+// attributes, and empty groups are ignored, and groups without a key are
+// inlined, following the `slog.Handler` rules.
+func addAttrs(dst fields.Fields, groups []string, attrs ...slog.Attr) {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+
+		if a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0 {
+			continue
+		}
+
+		target := dst
+
+		for _, g := range groups {
+			nested, ok := target[g].(fields.Fields)
+			if !ok {
+				nested = fields.Fields{}
+				target[g] = nested
+			}
+
+			target = nested
+		}
+
+		if a.Value.Kind() != slog.KindGroup {
+			target[a.Key] = a.Value.Any()
+
+			continue
+		}
+
+		if a.Key == "" {
+			addAttrs(target, nil, a.Value.Group()...)
+
+			continue
+		}
+
+		addAttrs(target, []string{a.Key}, a.Value.Group()...)
+	}
+}
+
+//////
+// Factory.
+//////
+
+// NewSlogHandler is the `SlogHandler` factory. `opts` is optional.
+//
+// Note: Don't use it with a logger which has a `output.Slog` output writing to
+// a `slog.Logger` backed by this handler, otherwise messages will loop.
+func NewSlogHandler(sypl *Sypl, opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{
+		fields: fields.Fields{},
+		groups: []string{},
+		sypl:   sypl,
+	}
+
+	if opts != nil {
+		h.options = *opts
+	}
+
+	return h
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The sypl Authors. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package sypl
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/saucelabs/sypl/fields"
+	"github.com/saucelabs/sypl/level"
+	"github.com/saucelabs/sypl/message"
+	"github.com/saucelabs/sypl/output"
+	"github.com/saucelabs/sypl/processor"
+)
+
+// Returns a processor which captures the processed messages.
+func captureProcessor(mu *sync.Mutex, captured *[]message.IMessage) processor.IProcessor {
+	return processor.New("Capture", func(m message.IMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		*captured = append(*captured, m)
+
+		return nil
+	})
+}
+
+func TestSlogHandler(t *testing.T) {
+	type want struct {
+		content string
+		fields  fields.Fields
+		level   level.Level
+	}
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want []want
+	}{
+		{
+			name: "Should work - level, and message",
+			log: func(l *slog.Logger) {
+				l.Warn("warn message")
+			},
+			want: []want{{content: "warn message", fields: fields.Fields{}, level: level.Warn}},
+		},
+		{
+			name: "Should work - attrs",
+			log: func(l *slog.Logger) {
+				l.With("a", 1).Info("info message", "b", "2")
+			},
+			want: []want{{content: "info message", fields: fields.Fields{"a": int64(1), "b": "2"}, level: level.Info}},
+		},
+		{
+			name: "Should work - groups",
+			log: func(l *slog.Logger) {
+				l.With("a", 1).WithGroup("g1").With("b", 2).WithGroup("g2").Error("error message", "c", 3, slog.Group("g3", "d", 4))
+			},
+			want: []want{{
+				content: "error message",
+				fields: fields.Fields{
+					"a": int64(1),
+					"g1": fields.Fields{
+						"b": int64(2),
+						"g2": fields.Fields{
+							"c":  int64(3),
+							"g3": fields.Fields{"d": int64(4)},
+						},
+					},
+				},
+				level: level.Error,
+			}},
+		},
+		{
+			name: "Should work - empty group is omitted",
+			log: func(l *slog.Logger) {
+				l.WithGroup("g1").Debug("debug message")
+			},
+			want: []want{{content: "debug message", fields: fields.Fields{}, level: level.Debug}},
+		},
+		{
+			name: "Should not print - level above max level",
+			log: func(l *slog.Logger) {
+				l.Log(context.Background(), level.SlogTrace, "trace message")
+			},
+			want: []want{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+
+			captured := []message.IMessage{}
+
+			buf, o := output.SafeBuffer(level.Debug, captureProcessor(&mu, &captured))
+
+			tt.log(slog.New(NewSlogHandler(New("slog", o), nil)))
+
+			if len(captured) != len(tt.want) {
+				t.Fatalf("Got %d messages, want %d", len(captured), len(tt.want))
+			}
+
+			for i, w := range tt.want {
+				m := captured[i]
+
+				if m.GetContent().GetOriginal() != w.content || m.GetLevel() != w.level {
+					t.Errorf("Got %s@%s, want %s@%s", m.GetContent().GetOriginal(), m.GetLevel(), w.content, w.level)
+				}
+
+				if diff := deep.Equal(m.GetFields(), w.fields); diff != nil {
+					t.Errorf("Fields diff: %v", diff)
+				}
+
+				if !strings.Contains(buf.String(), w.content) {
+					t.Errorf("Got %s, want it to contain %s", buf.String(), w.content)
+				}
+			}
+		})
+	}
+}
+
+func TestSlogHandler_AddSource(t *testing.T) {
+	var mu sync.Mutex
+
+	captured := []message.IMessage{}
+
+	_, o := output.SafeBuffer(level.Info, captureProcessor(&mu, &captured))
+
+	slog.New(NewSlogHandler(New("slog", o), &SlogHandlerOptions{AddSource: true})).Info("info message")
+
+	if len(captured) != 1 {
+		t.Fatalf("Got %d messages, want 1", len(captured))
+	}
+
+	source, ok := captured[0].GetFields()["source"].(string)
+	if !ok || !strings.Contains(source, "slog_test.go:") {
+		t.Errorf("Got source %v, want it to contain slog_test.go", captured[0].GetFields()["source"])
+	}
+}
@@ -6,11 +6,14 @@ package sypl
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strings"
+	"sync/atomic"
 
 	"github.com/saucelabs/sypl/debug"
 	"github.com/saucelabs/sypl/fields"
@@ -47,9 +50,14 @@ type Sypl struct {
 	Name string
 
 	// NOTE: Changes here may reflect in the `New(name string)` method (Child).
+	contextExtractors    []ContextExtractor
 	defaultIoWriterLevel level.Level
+	exitFunc             ExitFunc
+	exitHooks            []ExitHook
 	fields               fields.Fields
+	ordered              bool
 	outputs              []output.IOutput
+	stackTraceLevel      level.Level
 	status               status.Status
 }
 
@@ -123,6 +131,15 @@ func (sypl *Sypl) PrintMessage(messages ...message.IMessage) ISypl {
 	return sypl
 }
 
+// PrintMessageOrdered prints messages like `PrintMessage`, but each output
+// writes them in the specified order, regardless of the logger's ordering
+// mode.
+func (sypl *Sypl) PrintMessageOrdered(messages ...message.IMessage) ISypl {
+	sypl.processWithOrder(true, messages...)
+
+	return sypl
+}
+
 // PrintWithOptions is a more flexible way of printing, allowing to specify
 // a few message's options. For full-control over the message is possible
 // via `PrintMessage`.
@@ -299,6 +316,41 @@ func (sypl *Sypl) Fatalln(args ...interface{}) ISypl {
 	return sypl.Println(level.Fatal, args...)
 }
 
+// Panic prints @ the Fatal level - tagged with `PanicTag`, without exiting,
+// flushes outputs, and panics with the non-processed content.
+func (sypl *Sypl) Panic(args ...interface{}) {
+	sypl.PrintWithOptions(panicOptions(), level.Fatal, args...)
+
+	sypl.panic(fmt.Sprint(args...))
+}
+
+// Panicf prints according with the format @ the Fatal level - tagged with
+// `PanicTag`, without exiting, flushes outputs, and panics with the
+// non-processed content.
+func (sypl *Sypl) Panicf(format string, args ...interface{}) {
+	sypl.PrintfWithOptions(panicOptions(), level.Fatal, format, args...)
+
+	sypl.panic(fmt.Sprintf(format, args...))
+}
+
+// Paniclnf prints according with the format @ the Fatal level - tagged with
+// `PanicTag`, without exiting, also adding a new line to the end, flushes
+// outputs, and panics with the non-processed content.
+func (sypl *Sypl) Paniclnf(format string, args ...interface{}) {
+	sypl.PrintlnfWithOptions(panicOptions(), level.Fatal, format, args...)
+
+	sypl.panic(fmt.Sprintf(format+"\n", args...))
+}
+
+// Panicln prints, also adding a new line to the end @ the Fatal level - tagged
+// with `PanicTag`, without exiting, flushes outputs, and panics with the
+// non-processed content.
+func (sypl *Sypl) Panicln(args ...interface{}) {
+	sypl.PrintlnWithOptions(panicOptions(), level.Fatal, args...)
+
+	sypl.panic(fmt.Sprintln(args...))
+}
+
 // Error prints @ the Error level.
 func (sypl *Sypl) Error(args ...interface{}) ISypl {
 	return sypl.Print(level.Error, args...)
@@ -436,6 +488,132 @@ func (sypl *Sypl) Traceln(args ...interface{}) ISypl {
 	return sypl.Println(level.Trace, args...)
 }
 
+//////
+// IContextPrinter interface implementation.
+//////
+
+// PrintWithContext prints, adding fields extracted from the context by the
+// registered extractors.
+func (sypl *Sypl) PrintWithContext(ctx context.Context, l level.Level, args ...interface{}) ISypl {
+	return sypl.PrintWithOptions(sypl.optionsWithContext(ctx, options.New()), l, args...)
+}
+
+// PrintfWithContext prints according with the specified format, adding fields
+// extracted from the context by the registered extractors.
+func (sypl *Sypl) PrintfWithContext(ctx context.Context, l level.Level, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithOptions(sypl.optionsWithContext(ctx, options.New()), l, format, args...)
+}
+
+// PrintlnfWithContext prints according with the specified format, also adding
+// a new line to the end, and fields extracted from the context by the
+// registered extractors.
+func (sypl *Sypl) PrintlnfWithContext(ctx context.Context, l level.Level, format string, args ...interface{}) ISypl {
+	return sypl.PrintlnfWithOptions(sypl.optionsWithContext(ctx, options.New()), l, format, args...)
+}
+
+// PrintlnWithContext prints, also adding a new line to the end, and fields
+// extracted from the context by the registered extractors.
+func (sypl *Sypl) PrintlnWithContext(ctx context.Context, l level.Level, args ...interface{}) ISypl {
+	return sypl.PrintlnWithOptions(sypl.optionsWithContext(ctx, options.New()), l, args...)
+}
+
+// PrintWithOptionsAndContext is like `PrintWithOptions`, adding fields
+// extracted from the context by the registered extractors. Options' fields
+// have precedence.
+func (sypl *Sypl) PrintWithOptionsAndContext(
+	ctx context.Context,
+	o *options.Options,
+	l level.Level,
+	args ...interface{},
+) ISypl {
+	return sypl.PrintWithOptions(sypl.optionsWithContext(ctx, o), l, args...)
+}
+
+// PrintfWithOptionsAndContext is like `PrintfWithOptions`, adding fields
+// extracted from the context by the registered extractors. Options' fields
+// have precedence.
+func (sypl *Sypl) PrintfWithOptionsAndContext(
+	ctx context.Context,
+	o *options.Options,
+	l level.Level,
+	format string,
+	args ...interface{},
+) ISypl {
+	return sypl.PrintfWithOptions(sypl.optionsWithContext(ctx, o), l, format, args...)
+}
+
+// FatalContext prints like `Fatal`, adding fields extracted from the context,
+// and exit with os.Exit(1).
+func (sypl *Sypl) FatalContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Fatal, args...)
+}
+
+// FatalfContext prints like `Fatalf`, adding fields extracted from the
+// context, and exit with os.Exit(1).
+func (sypl *Sypl) FatalfContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Fatal, format, args...)
+}
+
+// ErrorContext prints @ the Error level, adding fields extracted from the
+// context.
+func (sypl *Sypl) ErrorContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Error, args...)
+}
+
+// ErrorfContext prints according with the format @ the Error level, adding
+// fields extracted from the context.
+func (sypl *Sypl) ErrorfContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Error, format, args...)
+}
+
+// InfoContext prints @ the Info level, adding fields extracted from the
+// context.
+func (sypl *Sypl) InfoContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Info, args...)
+}
+
+// InfofContext prints according with the format @ the Info level, adding
+// fields extracted from the context.
+func (sypl *Sypl) InfofContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Info, format, args...)
+}
+
+// WarnContext prints @ the Warn level, adding fields extracted from the
+// context.
+func (sypl *Sypl) WarnContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Warn, args...)
+}
+
+// WarnfContext prints according with the format @ the Warn level, adding
+// fields extracted from the context.
+func (sypl *Sypl) WarnfContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Warn, format, args...)
+}
+
+// DebugContext prints @ the Debug level, adding fields extracted from the
+// context.
+func (sypl *Sypl) DebugContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Debug, args...)
+}
+
+// DebugfContext prints according with the format @ the Debug level, adding
+// fields extracted from the context.
+func (sypl *Sypl) DebugfContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Debug, format, args...)
+}
+
+// TraceContext prints @ the Trace level, adding fields extracted from the
+// context.
+func (sypl *Sypl) TraceContext(ctx context.Context, args ...interface{}) ISypl {
+	return sypl.PrintWithContext(ctx, level.Trace, args...)
+}
+
+// TracefContext prints according with the format @ the Trace level, adding
+// fields extracted from the context.
+func (sypl *Sypl) TracefContext(ctx context.Context, format string, args ...interface{}) ISypl {
+	return sypl.PrintfWithContext(ctx, level.Trace, format, args...)
+}
+
 //////
 // ISypl interface implementation.
 //////
@@ -472,6 +650,74 @@ func (sypl *Sypl) Breakpoint(name string, data ...interface{}) ISypl {
 	return sypl
 }
 
+// AddContextExtractors adds one or more context extractors. They're used by
+// the context-aware printers.
+func (sypl *Sypl) AddContextExtractors(extractors ...ContextExtractor) ISypl {
+	sypl.contextExtractors = append(sypl.contextExtractors, extractors...)
+
+	return sypl
+}
+
+// GetContextExtractors returns registered context extractors.
+func (sypl *Sypl) GetContextExtractors() []ContextExtractor {
+	return sypl.contextExtractors
+}
+
+// GetExitFunc returns the exit function.
+func (sypl *Sypl) GetExitFunc() ExitFunc {
+	return sypl.exitFunc
+}
+
+// SetExitFunc sets the function called, with the exit code, after printing
+// `Fatal` messages. Default is `os.Exit`.
+func (sypl *Sypl) SetExitFunc(f ExitFunc) ISypl {
+	sypl.exitFunc = f
+
+	return sypl
+}
+
+// AddExitHooks adds one or more exit hooks. They run, in the order they were
+// added, before outputs are flushed, and before exiting.
+func (sypl *Sypl) AddExitHooks(hooks ...ExitHook) ISypl {
+	sypl.exitHooks = append(sypl.exitHooks, hooks...)
+
+	return sypl
+}
+
+// GetExitHooks returns registered exit hooks.
+func (sypl *Sypl) GetExitHooks() []ExitHook {
+	return sypl.exitHooks
+}
+
+// GetOrdered returns true if the logger is in the ordering mode.
+func (sypl *Sypl) GetOrdered() bool {
+	return sypl.ordered
+}
+
+// SetOrdered sets the ordering mode. If set, messages printed in a single call,
+// e.g.: `PrintMessage(m1, m2, m3)`, are written by each output in the specified
+// order (FIFO). Outputs still write concurrently. Default is `false`, where
+// each message is processed concurrently.
+func (sypl *Sypl) SetOrdered(ordered bool) ISypl {
+	sypl.ordered = ordered
+
+	return sypl
+}
+
+// GetStackTraceLevel returns the stack trace level.
+func (sypl *Sypl) GetStackTraceLevel() level.Level {
+	return sypl.stackTraceLevel
+}
+
+// SetStackTraceLevel sets the stack trace level. A stack trace is captured for
+// messages at, or above (more severe) the specified level. Default is `None`,
+// meaning disabled.
+func (sypl *Sypl) SetStackTraceLevel(l level.Level) ISypl {
+	sypl.stackTraceLevel = l
+
+	return sypl
+}
+
 // GetFields returns the structured fields.
 func (sypl *Sypl) GetFields() fields.Fields {
 	return sypl.fields
@@ -504,6 +750,26 @@ func (sypl *Sypl) SetMaxLevel(l level.Level) ISypl {
 	return sypl
 }
 
+// GetMinLevel returns the `minLevel` of all outputs.
+func (sypl *Sypl) GetMinLevel() map[string]level.Level {
+	levelMap := map[string]level.Level{}
+
+	for _, output := range sypl.GetOutputs() {
+		levelMap[output.GetName()] = output.GetMinLevel()
+	}
+
+	return levelMap
+}
+
+// SetMinLevel sets the `minLevel` of all outputs.
+func (sypl *Sypl) SetMinLevel(l level.Level) ISypl {
+	for _, output := range sypl.GetOutputs() {
+		output.SetMinLevel(l)
+	}
+
+	return sypl
+}
+
 // AddOutputs adds one or more outputs.
 func (sypl *Sypl) AddOutputs(outputs ...output.IOutput) ISypl {
 	sypl.outputs = append(sypl.outputs, outputs...)
@@ -552,79 +818,100 @@ func (sypl *Sypl) GetOutputsNames() []string {
 	return outputsNames
 }
 
+// Flush flushes all outputs.
+func (sypl *Sypl) Flush() error {
+	errs := []error{}
+
+	for _, o := range sypl.outputs {
+		errs = append(errs, o.Flush())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close flushes, and closes all outputs. Writing to a closed output returns
+// `output.ErrOutputClosed`.
+//
+// Note: Outputs are shared with child loggers, closing them affects all.
+func (sypl *Sypl) Close() error {
+	errs := []error{}
+
+	for _, o := range sypl.outputs {
+		errs = append(errs, o.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
 // New creates a child logger. The child logger is an accurate, efficient and
 // shallow copy of the parent logger. Changes to internals, such as the state of
 // outputs, and processors, are reflected cross all other loggers.
 func (sypl *Sypl) New(name string) *Sypl {
 	s := New(name, sypl.outputs...)
 
+	s.contextExtractors = append(s.contextExtractors, sypl.contextExtractors...)
 	s.defaultIoWriterLevel = sypl.defaultIoWriterLevel
+	s.exitFunc = sypl.exitFunc
+	s.exitHooks = append(s.exitHooks, sypl.exitHooks...)
 	s.fields = sypl.fields
+	s.ordered = sypl.ordered
+	s.stackTraceLevel = sypl.stackTraceLevel
 	s.status = sypl.status
 
 	return s
 }
 
-// Process messages, per output, and process accordingly.
+// Process messages, per output, and process accordingly. Messages are
+// ordered if the logger is set to.
 func (sypl *Sypl) process(messages ...message.IMessage) {
+	ordered := false
+
+	// Nil logger is handled by `processWithOrder`.
+	if sypl != nil {
+		ordered = sypl.ordered
+	}
+
+	sypl.processWithOrder(ordered, messages...)
+}
+
+// Process messages, per output, and process accordingly. If `ordered`, each
+// output writes messages in the same order they were specified.
+func (sypl *Sypl) processWithOrder(ordered bool, messages ...message.IMessage) {
 	if sypl == nil {
 		log.Fatalf("%s %s", shared.ErrorPrefix, ErrSyplNotInitialized)
 	}
 
-	shouldExit := false
+	// Caller, and stack trace should be captured before the goroutine hop.
+	c := caller()
 
-	g := new(errgroup.Group)
+	var st []runtime.Frame
 
 	for _, m := range messages {
-		// https://golang.org/doc/faq#closures_and_goroutines
-		m := m
-
-		g.Go(func() error {
-			// Do nothing if message as no context, or flagged with `SkipAndMute`.
-			if m.GetContent().GetOriginal() == "" &&
-				m.GetFlag() == flag.SkipAndMute {
-				return nil
-			}
-
-			// Should allows to filter logging by components names.
-			syplFilterEnvVar := os.Getenv(shared.FilterEnvVar)
-
-			if syplFilterEnvVar != "" &&
-				!strings.Contains(syplFilterEnvVar, sypl.GetName()) {
-				return nil
-			}
-
-			// Should allows to specify `Output`(s).
-			outputsNames := sypl.GetOutputsNames()
+		if m.GetCaller().PC == 0 {
+			m.SetCaller(c)
+		}
 
-			if len(m.GetOutputsNames()) > 0 {
-				outputsNames = m.GetOutputsNames()
+		if sypl.shouldCaptureStackTrace(m) {
+			if st == nil {
+				st = stackTrace()
 			}
 
-			m.SetOutputsNames(outputsNames)
-
-			// Should allows to set global fields.
-			// Per-message fields should have precedence.
-			finalFields := fields.Fields{}
-			finalFields = fields.Copy(sypl.GetFields(), finalFields)
-			finalFields = fields.Copy(m.GetFields(), finalFields)
-			m.SetFields(finalFields)
-
-			sypl.processOutputs(m, strings.Join(outputsNames, ","))
+			m.SetStackTrace(st)
+		}
+	}
 
-			if m.GetLevel() == level.Fatal {
-				shouldExit = true
-			}
+	var shouldExit bool
 
-			return nil
-		})
+	if ordered {
+		shouldExit = sypl.processOrdered(messages...)
+	} else {
+		shouldExit = sypl.processConcurrently(messages...)
 	}
 
-	_ = g.Wait()
-
-	// Should exit if `level` is `Fatal`.
+	// Should exit if `level` is `Fatal`. Exit hooks run, and outputs are
+	// flushed before.
 	if shouldExit {
-		os.Exit(1)
+		sypl.exit(1)
 	}
 }
 
@@ -666,31 +953,165 @@ func mergeOptions(m message.IMessage, o *options.Options) message.IMessage {
 	return m
 }
 
-// Outputs logic of the Process method.
-func (sypl *Sypl) processOutputs(m message.IMessage, outputsNames string) {
+// Processes messages concurrently, each one in its own goroutine, and each
+// output also in its own goroutine. Returns true if any message should exit.
+func (sypl *Sypl) processConcurrently(messages ...message.IMessage) bool {
+	// Set by concurrently processed messages.
+	var shouldExit int32
+
 	g := new(errgroup.Group)
 
-	for _, o := range sypl.outputs {
+	for _, m := range messages {
 		// https://golang.org/doc/faq#closures_and_goroutines
-		o := o
 		m := m
 
-		// Message is isolated per `Output`.
-		msg := message.Copy(m)
+		g.Go(func() error {
+			outputsNames, ok := sypl.prepareMessage(m)
+			if !ok {
+				return nil
+			}
+
+			sypl.processOutputs(m, outputsNames)
+
+			if shouldExitOn(m) {
+				atomic.StoreInt32(&shouldExit, 1)
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return atomic.LoadInt32(&shouldExit) == 1
+}
+
+// Processes messages keeping order: outputs still write concurrently, each
+// one in its own goroutine, but each output writes messages in the order they
+// were specified (FIFO). Returns true if any message should exit.
+func (sypl *Sypl) processOrdered(messages ...message.IMessage) bool {
+	shouldExit := false
+
+	// Messages, per output, in order.
+	queues := make([][]message.IMessage, len(sypl.outputs))
+
+	for _, m := range messages {
+		outputsNames, ok := sypl.prepareMessage(m)
+		if !ok {
+			continue
+		}
+
+		for i, o := range sypl.outputs {
+			if msg, ok := sypl.messageForOutput(o, m, outputsNames); ok {
+				queues[i] = append(queues[i], msg)
+			}
+		}
+
+		if shouldExitOn(m) {
+			shouldExit = true
+		}
+	}
+
+	g := new(errgroup.Group)
+
+	for i, o := range sypl.outputs {
+		// https://golang.org/doc/faq#closures_and_goroutines
+		o := o
+		queue := queues[i]
 
-		// Should only use enabled Outputs, and named (listed) ones.
-		if o.GetStatus() == status.Enabled && strings.Contains(outputsNames, o.GetName()) {
-			msg.SetComponentName(sypl.GetName())
-			msg.SetOutputName(o.GetName())
+		if len(queue) == 0 {
+			continue
+		}
 
-			// Debug capability.
-			// Should only run if Debug env var is set.
-			if os.Getenv(shared.DebugEnvVar) != "" {
-				msg.SetDebugEnvVarRegexes(
-					debug.New(msg.GetComponentName(), msg.GetOutputName()),
-				)
+		g.Go(func() error {
+			for _, msg := range queue {
+				_ = o.Write(msg)
 			}
 
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return shouldExit
+}
+
+// Prepares the message to be processed by outputs, setting outputs names, and
+// merging global fields. Returns the comma-separated outputs names, and false
+// if the message should not be processed.
+func (sypl *Sypl) prepareMessage(m message.IMessage) (string, bool) {
+	// Do nothing if message as no context, or flagged with `SkipAndMute`.
+	if m.GetContent().GetOriginal() == "" &&
+		m.GetFlag() == flag.SkipAndMute {
+		return "", false
+	}
+
+	// Should allows to filter logging by components names.
+	syplFilterEnvVar := os.Getenv(shared.FilterEnvVar)
+
+	if syplFilterEnvVar != "" &&
+		!strings.Contains(syplFilterEnvVar, sypl.GetName()) {
+		return "", false
+	}
+
+	// Should allows to specify `Output`(s).
+	outputsNames := sypl.GetOutputsNames()
+
+	if len(m.GetOutputsNames()) > 0 {
+		outputsNames = m.GetOutputsNames()
+	}
+
+	m.SetOutputsNames(outputsNames)
+
+	// Should allows to set global fields.
+	// Per-message fields should have precedence.
+	finalFields := fields.Fields{}
+	finalFields = fields.Copy(sypl.GetFields(), finalFields)
+	finalFields = fields.Copy(m.GetFields(), finalFields)
+	m.SetFields(finalFields)
+
+	return strings.Join(outputsNames, ","), true
+}
+
+// Returns a copy of the message, isolated per `Output`, and false if the
+// output should not write it.
+func (sypl *Sypl) messageForOutput(
+	o output.IOutput,
+	m message.IMessage,
+	outputsNames string,
+) (message.IMessage, bool) {
+	// Should only use enabled Outputs, and named (listed) ones.
+	if o.GetStatus() != status.Enabled || !strings.Contains(outputsNames, o.GetName()) {
+		return nil, false
+	}
+
+	// Message is isolated per `Output`.
+	msg := message.Copy(m)
+
+	msg.SetComponentName(sypl.GetName())
+	msg.SetOutputName(o.GetName())
+
+	// Debug capability.
+	// Should only run if Debug env var is set.
+	if os.Getenv(shared.DebugEnvVar) != "" {
+		msg.SetDebugEnvVarRegexes(
+			debug.New(msg.GetComponentName(), msg.GetOutputName()),
+		)
+	}
+
+	return msg, true
+}
+
+// Outputs logic of the Process method.
+func (sypl *Sypl) processOutputs(m message.IMessage, outputsNames string) {
+	g := new(errgroup.Group)
+
+	for _, o := range sypl.outputs {
+		// https://golang.org/doc/faq#closures_and_goroutines
+		o := o
+
+		if msg, ok := sypl.messageForOutput(o, m, outputsNames); ok {
 			g.Go(func() error {
 				return o.Write(msg)
 			})
@@ -700,6 +1121,15 @@ func (sypl *Sypl) processOutputs(m message.IMessage, outputsNames string) {
 	_ = g.Wait()
 }
 
+// Stack trace should only be captured if enabled, not captured yet, and if the
+// message is at, or above the stack trace level.
+func (sypl *Sypl) shouldCaptureStackTrace(m message.IMessage) bool {
+	return sypl.stackTraceLevel != level.None &&
+		m.GetStackTrace() == nil &&
+		m.GetLevel() != level.None &&
+		m.GetLevel() <= sypl.stackTraceLevel
+}
+
 //////
 // Factory.
 //////
@@ -709,9 +1139,13 @@ func New(name string, outputs ...output.IOutput) *Sypl {
 	return &Sypl{
 		Name: name,
 
+		contextExtractors:    []ContextExtractor{},
 		defaultIoWriterLevel: level.None,
+		exitFunc:             os.Exit,
+		exitHooks:            []ExitHook{},
 		fields:               fields.Fields{},
 		outputs:              outputs,
+		stackTraceLevel:      level.None,
 		status:               status.Enabled,
 	}
 }
@@ -730,12 +1164,16 @@ func NewDefault(name string, maxLevel level.Level, processors ...processor.IProc
 	return &Sypl{
 		Name: name,
 
+		contextExtractors:    []ContextExtractor{},
 		defaultIoWriterLevel: level.None,
+		exitFunc:             os.Exit,
+		exitHooks:            []ExitHook{},
 		fields:               fields.Fields{},
 		outputs: []output.IOutput{
 			output.Console(maxLevel, consoleProcessors...).SetFormatter(formatter.Text()),
 			output.StdErr(processors...).SetFormatter(formatter.Text()),
 		},
-		status: status.Enabled,
+		stackTraceLevel: level.None,
+		status:          status.Enabled,
 	}
 }
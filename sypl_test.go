@@ -7,12 +7,14 @@ package sypl
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/saucelabs/sypl/flag"
 	"github.com/saucelabs/sypl/level"
 	"github.com/saucelabs/sypl/message"
 	"github.com/saucelabs/sypl/options"
@@ -696,3 +698,150 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestSypl_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sypl.log")
+
+	asyncOutput := output.Async(output.File(path, level.Trace), nil)
+
+	l := New("close", asyncOutput, output.Console(level.Trace))
+
+	l.Info(shared.DefaultContentOutput)
+
+	if err := l.Flush(); err != nil {
+		t.Fatalf("Flush failed: %s", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %s", err)
+	}
+
+	if string(content) != shared.DefaultContentOutput {
+		t.Errorf("Got %s, want %s", content, shared.DefaultContentOutput)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	for _, o := range l.GetOutputs() {
+		if err := o.Write(message.New(level.Info, shared.DefaultContentOutput)); !errors.Is(err, output.ErrOutputClosed) {
+			t.Errorf("Output %s: got %v, want %v", o.GetName(), err, output.ErrOutputClosed)
+		}
+	}
+}
+
+func TestNewDefault(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       flag.Flag
+		level      level.Level
+		wantStdout bool
+		wantStderr bool
+	}{
+		{name: "Should work - info", level: level.Info, wantStdout: true},
+		{name: "Should work - error", level: level.Error, wantStderr: true},
+		{name: "Should work - forced error", flag: flag.Force, level: level.Error, wantStderr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewDefault("default", level.Trace)
+
+			stdout := &bytes.Buffer{}
+			l.GetOutput("Console").GetBuiltinLogger().SetOutput(stdout)
+
+			stderr := &bytes.Buffer{}
+			l.GetOutput("StdErr").GetBuiltinLogger().SetOutput(stderr)
+
+			o := options.New()
+			o.Flag = tt.flag
+
+			l.PrintWithOptions(o, tt.level, shared.DefaultContentOutput)
+
+			if got := stdout.Len() > 0; got != tt.wantStdout {
+				t.Errorf("Got %v stdout, want %v: %q", got, tt.wantStdout, stdout.String())
+			}
+
+			if got := stderr.Len() > 0; got != tt.wantStderr {
+				t.Errorf("Got %v stderr, want %v: %q", got, tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestSypl_Ordered(t *testing.T) {
+	const n = 50
+
+	tests := []struct {
+		name  string
+		print func(l *Sypl, messages ...message.IMessage)
+	}{
+		{
+			name: "Should work - SetOrdered",
+			print: func(l *Sypl, messages ...message.IMessage) {
+				l.SetOrdered(true).PrintMessage(messages...)
+			},
+		},
+		{
+			name: "Should work - child",
+			print: func(l *Sypl, messages ...message.IMessage) {
+				l.SetOrdered(true)
+				l.New("child").PrintMessage(messages...)
+			},
+		},
+		{
+			name: "Should work - PrintMessageOrdered",
+			print: func(l *Sypl, messages ...message.IMessage) {
+				l.PrintMessageOrdered(messages...)
+			},
+		},
+		{
+			name: "Should work - PrintMessagesToOutputs",
+			print: func(l *Sypl, messages ...message.IMessage) {
+				mtos := []MessageToOutput{}
+
+				for _, m := range messages {
+					for _, o := range l.GetOutputs() {
+						mtos = append(mtos, MessageToOutput{
+							Content:    m.GetContent().GetOriginal(),
+							Level:      m.GetLevel(),
+							OutputName: o.GetName(),
+						})
+					}
+				}
+
+				l.SetOrdered(true).PrintMessagesToOutputs(mtos...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf1, o1 := output.SafeBuffer(level.Trace)
+			o1.SetName("Buffer1")
+
+			buf2, o2 := output.SafeBuffer(level.Trace)
+			o2.SetName("Buffer2")
+
+			messages := []message.IMessage{}
+			want := ""
+
+			for i := 0; i < n; i++ {
+				content := fmt.Sprintf("%d,", i)
+
+				messages = append(messages, message.New(level.Info, content))
+				want += content
+			}
+
+			tt.print(New("ordered", o1, o2), messages...)
+
+			if buf1.String() != want {
+				t.Errorf("Buffer1: got %s, want %s", buf1.String(), want)
+			}
+
+			if buf2.String() != want {
+				t.Errorf("Buffer2: got %s, want %s", buf2.String(), want)
+			}
+		})
+	}
+}